@@ -0,0 +1,239 @@
+package caf
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	FormatLinearPCM     = stringToChunkType("lpcm")
+	FormatAppleLossless = stringToChunkType("alac")
+	FormatMPEG4AAC      = stringToChunkType("aac ")
+)
+
+// aacFormats are the format IDs of AAC and its low delay and high efficiency
+// variants.
+var aacFormats = []FourByteString{
+	FormatMPEG4AAC,
+	stringToChunkType("aace"),
+	stringToChunkType("aacf"),
+	stringToChunkType("aach"),
+	stringToChunkType("aacp"),
+	stringToChunkType("aacl"),
+}
+
+// Format flags for linear PCM audio as stored in a desc chunk. The file format
+// defines only these two; integer samples are always signed.
+const (
+	CAFLinearPCMFormatFlagIsFloat        uint32 = 1 << 0
+	CAFLinearPCMFormatFlagIsLittleEndian uint32 = 1 << 1
+
+	linearPCMFormatFlagsMask = CAFLinearPCMFormatFlagIsFloat | CAFLinearPCMFormatFlagIsLittleEndian
+)
+
+// Format flags for linear PCM audio in a CoreAudio AudioStreamBasicDescription.
+// These describe samples in memory, not in a CAF file, where bit 1 means
+// little endian instead; convert before storing them in a desc chunk.
+const (
+	LinearPCMFormatFlagIsFloat         uint32 = 1 << 0
+	LinearPCMFormatFlagIsBigEndian     uint32 = 1 << 1
+	LinearPCMFormatFlagIsSignedInteger uint32 = 1 << 2
+	LinearPCMFormatFlagIsPacked        uint32 = 1 << 3
+)
+
+// NewMonoAudioFormat returns a one channel, big endian, signed integer linear
+// PCM format. bitDepth must be a multiple of 8 no larger than 64. Big endian
+// signed integer is CAF linear PCM with no format flags set.
+func NewMonoAudioFormat(sampleRate float64, bitDepth uint32) (AudioFormat, error) {
+	return newIntegerPCMAudioFormat(sampleRate, bitDepth, 1)
+}
+
+// NewStereoAudioFormat is like NewMonoAudioFormat but with two channels.
+func NewStereoAudioFormat(sampleRate float64, bitDepth uint32) (AudioFormat, error) {
+	return newIntegerPCMAudioFormat(sampleRate, bitDepth, 2)
+}
+
+func newIntegerPCMAudioFormat(sampleRate float64, bitDepth, channels uint32) (AudioFormat, error) {
+	if !(sampleRate > 0) {
+		return AudioFormat{}, ErrInvalidSampleRate
+	}
+	if bitDepth == 0 || bitDepth%8 != 0 || bitDepth > 64 {
+		return AudioFormat{}, ErrInvalidBitsPerChannel
+	}
+	return AudioFormat{
+		SampleRate:        sampleRate,
+		FormatID:          FormatLinearPCM,
+		BytesPerPacket:    bitDepth / 8 * channels,
+		FramesPerPacket:   1,
+		ChannelsPerPacket: channels,
+		BitsPerChannel:    bitDepth,
+	}, nil
+}
+
+// IsPCM reports whether the format is linear PCM.
+func (c *AudioFormat) IsPCM() bool {
+	return c.FormatID == FormatLinearPCM
+}
+
+// IsFloat reports whether the format is floating point linear PCM.
+func (c *AudioFormat) IsFloat() bool {
+	return c.IsPCM() && c.FormatFlags&CAFLinearPCMFormatFlagIsFloat != 0
+}
+
+// IsALAC reports whether the format is Apple Lossless. ALAC packets vary in
+// size, so a BytesPerPacket other than 0 is logged as suspicious.
+func (c *AudioFormat) IsALAC() bool {
+	if c.FormatID != FormatAppleLossless {
+		return false
+	}
+	if c.BytesPerPacket != 0 {
+		logger.Debugf("Apple Lossless format has %d bytes per packet, expected 0", c.BytesPerPacket)
+	}
+	return true
+}
+
+// IsAAC reports whether the format is AAC or one of its variants.
+func (c *AudioFormat) IsAAC() bool {
+	for _, id := range aacFormats {
+		if c.FormatID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCompressed reports whether the format has variable sized packets.
+func (c *AudioFormat) IsCompressed() bool {
+	return c.BytesPerPacket == 0
+}
+
+// RequiresPacketTable reports whether packets vary in size or in the number
+// of frames they hold, so that a file needs a packet table to locate them.
+func (c *AudioFormat) RequiresPacketTable() bool {
+	return c.BytesPerPacket == 0 || c.FramesPerPacket == 0
+}
+
+// ByteOrderIsLittleEndian reports whether samples are stored little endian.
+func (c *AudioFormat) ByteOrderIsLittleEndian() bool {
+	return c.FormatFlags&CAFLinearPCMFormatFlagIsLittleEndian != 0
+}
+
+// SampleFormatIsFloat reports whether the float flag is set.
+func (c *AudioFormat) SampleFormatIsFloat() bool {
+	return c.FormatFlags&CAFLinearPCMFormatFlagIsFloat != 0
+}
+
+// SampleFormatIsSignedInteger reports whether samples are signed integers,
+// which in a CAF file is every linear PCM format without the float flag.
+func (c *AudioFormat) SampleFormatIsSignedInteger() bool {
+	return c.IsPCM() && !c.SampleFormatIsFloat()
+}
+
+// SampleFormatIsPacked reports whether samples use all bits of their
+// channel. CAF has no packed flag, so this compares the sample size with the
+// bytes per packet.
+func (c *AudioFormat) SampleFormatIsPacked() bool {
+	return c.BytesPerPacket != 0 && c.BitsPerChannel*c.ChannelsPerPacket*c.FramesPerPacket == c.BytesPerPacket*8
+}
+
+// Validate checks that the fields of the format are consistent with each
+// other, returning the first problem found.
+func (c *AudioFormat) Validate() error {
+	if !(c.SampleRate > 0) {
+		return ErrInvalidSampleRate
+	}
+	if c.ChannelsPerPacket == 0 {
+		return ErrInvalidChannelCount
+	}
+	if c.BytesPerPacket != 0 && c.FramesPerPacket == 0 {
+		return ErrInvalidFramesPerPacket
+	}
+	if c.IsPCM() {
+		if c.BitsPerChannel == 0 || c.BitsPerChannel%8 != 0 {
+			return ErrInvalidBitsPerChannel
+		}
+		if c.BytesPerPacket != 0 && c.BytesPerPacket != c.BitsPerChannel/8*c.ChannelsPerPacket*c.FramesPerPacket {
+			return ErrInvalidBytesPerPacket
+		}
+	}
+	return nil
+}
+
+// FrameSize returns the number of bytes in one frame of constant bit rate
+// audio, or 0 for variable bit rate formats.
+func (c *AudioFormat) FrameSize() uint32 {
+	if c.BytesPerPacket == 0 {
+		return 0
+	}
+	return c.BitsPerChannel / 8 * c.ChannelsPerPacket
+}
+
+// BitsPerFrame returns the number of bits in one frame of interleaved
+// samples, or 0 for compressed formats without a sample size.
+func (c *AudioFormat) BitsPerFrame() uint32 {
+	return c.BitsPerChannel * c.ChannelsPerPacket
+}
+
+// BytesPerFrame returns BitsPerFrame in bytes, rounded up.
+func (c *AudioFormat) BytesPerFrame() uint32 {
+	return (c.BitsPerFrame() + 7) / 8
+}
+
+// IsInterleaved reports whether each packet holds its frames with all
+// channels' samples side by side, that is whether BytesPerPacket equals
+// BytesPerFrame times FramesPerPacket.
+func (c *AudioFormat) IsInterleaved() bool {
+	return c.BytesPerPacket == c.BytesPerFrame()*c.FramesPerPacket
+}
+
+// PacketSizeBytes returns the number of bytes in one packet of constant bit
+// rate audio, or 0 for variable bit rate formats.
+func (c *AudioFormat) PacketSizeBytes() uint32 {
+	return c.FrameSize() * c.FramesPerPacket
+}
+
+// PacketDuration returns the playing time of one packet.
+func (c *AudioFormat) PacketDuration() (time.Duration, error) {
+	if c.SampleRate <= 0 {
+		return 0, ErrInvalidSampleRate
+	}
+	if c.FramesPerPacket == 0 {
+		return 0, ErrInvalidFramesPerPacket
+	}
+	return time.Duration(float64(c.FramesPerPacket) / c.SampleRate * float64(time.Second)), nil
+}
+
+// EquivalentTo reports whether the two formats describe the same samples.
+// Linear PCM flags outside the defined format flags are ignored; other
+// formats must have identical flags.
+func (c *AudioFormat) EquivalentTo(other AudioFormat) bool {
+	if c.FormatID != other.FormatID || c.SampleRate != other.SampleRate ||
+		c.ChannelsPerPacket != other.ChannelsPerPacket || c.BitsPerChannel != other.BitsPerChannel {
+		return false
+	}
+	if c.IsPCM() {
+		return c.FormatFlags&linearPCMFormatFlagsMask == other.FormatFlags&linearPCMFormatFlagsMask
+	}
+	return c.FormatFlags == other.FormatFlags
+}
+
+var formatIDNames = map[FourByteString]string{
+	FormatLinearPCM:           "Linear PCM",
+	FormatMPEG4AAC:            "AAC",
+	FormatAppleLossless:       "Apple Lossless",
+	stringToChunkType(".mp1"): "MPEG-1 Layer I",
+	stringToChunkType(".mp2"): "MPEG-1 Layer II",
+	stringToChunkType(".mp3"): "MPEG-1 Layer III",
+	stringToChunkType("alaw"): "A-Law",
+	stringToChunkType("ulaw"): "µ-Law",
+	stringToChunkType("opus"): "Opus",
+}
+
+// FormatIDString returns the codec name for the format ID, or
+// "Unknown (xxxx)" for IDs it does not recognise.
+func (c *AudioFormat) FormatIDString() string {
+	if name, ok := formatIDNames[c.FormatID]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%v)", c.FormatID)
+}
@@ -0,0 +1,295 @@
+package caf
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAudioFormatKind(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     AudioFormat
+		pcm        bool
+		float      bool
+		compressed bool
+	}{
+		{"integer pcm", AudioFormat{FormatID: FormatLinearPCM, FormatFlags: CAFLinearPCMFormatFlagIsLittleEndian, BytesPerPacket: 4}, true, false, false},
+		{"float pcm", AudioFormat{FormatID: FormatLinearPCM, FormatFlags: CAFLinearPCMFormatFlagIsFloat, BytesPerPacket: 8}, true, true, false},
+		{"opus", AudioFormat{FormatID: stringToChunkType("opus"), FormatFlags: CAFLinearPCMFormatFlagIsFloat}, false, false, true},
+	}
+	for _, test := range tests {
+		if pcm := test.format.IsPCM(); pcm != test.pcm {
+			t.Errorf("%s: expected IsPCM %v, got %v", test.name, test.pcm, pcm)
+		}
+		if float := test.format.IsFloat(); float != test.float {
+			t.Errorf("%s: expected IsFloat %v, got %v", test.name, test.float, float)
+		}
+		if compressed := test.format.IsCompressed(); compressed != test.compressed {
+			t.Errorf("%s: expected IsCompressed %v, got %v", test.name, test.compressed, compressed)
+		}
+	}
+}
+
+func TestLinearPCMFormatFlags(t *testing.T) {
+	for flags := uint32(0); flags < 16; flags++ {
+		buf := &bytes.Buffer{}
+		original := AudioFormat{FormatID: FormatLinearPCM, FormatFlags: flags}
+		if err := original.encode(buf); err != nil {
+			t.Fatal(err)
+		}
+		var af AudioFormat
+		if err := af.decode(buf); err != nil {
+			t.Fatal(err)
+		}
+		if af.FormatFlags != flags {
+			t.Errorf("expected flags %#x, got %#x", flags, af.FormatFlags)
+		}
+		if af.SampleFormatIsFloat() != (flags&CAFLinearPCMFormatFlagIsFloat != 0) {
+			t.Errorf("flags %#x: unexpected SampleFormatIsFloat", flags)
+		}
+		if af.ByteOrderIsLittleEndian() != (flags&CAFLinearPCMFormatFlagIsLittleEndian != 0) {
+			t.Errorf("flags %#x: unexpected ByteOrderIsLittleEndian", flags)
+		}
+		if af.SampleFormatIsSignedInteger() != (flags&CAFLinearPCMFormatFlagIsFloat == 0) {
+			t.Errorf("flags %#x: unexpected SampleFormatIsSignedInteger", flags)
+		}
+	}
+}
+
+func TestSampleFormatIsPacked(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   AudioFormat
+		expected bool
+	}{
+		{"16-bit stereo", AudioFormat{FormatID: FormatLinearPCM, BytesPerPacket: 4, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: 16}, true},
+		{"24 bits in 32", AudioFormat{FormatID: FormatLinearPCM, BytesPerPacket: 8, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: 24}, false},
+		{"variable packets", AudioFormat{FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2}, false},
+	}
+	for _, test := range tests {
+		if packed := test.format.SampleFormatIsPacked(); packed != test.expected {
+			t.Errorf("%s: expected SampleFormatIsPacked %v, got %v", test.name, test.expected, packed)
+		}
+	}
+}
+
+func TestAudioFormatValidate(t *testing.T) {
+	valid := AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM, BytesPerPacket: 4, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: 16}
+	tests := []struct {
+		name   string
+		modify func(af *AudioFormat)
+		err    error
+	}{
+		{"valid pcm", func(af *AudioFormat) {}, nil},
+		{"valid opus", func(af *AudioFormat) {
+			*af = AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2}
+		}, nil},
+		{"zero sample rate", func(af *AudioFormat) { af.SampleRate = 0 }, ErrInvalidSampleRate},
+		{"negative sample rate", func(af *AudioFormat) { af.SampleRate = -1 }, ErrInvalidSampleRate},
+		{"no channels", func(af *AudioFormat) { af.ChannelsPerPacket = 0 }, ErrInvalidChannelCount},
+		{"odd bit depth", func(af *AudioFormat) { af.BitsPerChannel = 12 }, ErrInvalidBitsPerChannel},
+		{"zero bit depth", func(af *AudioFormat) { af.BitsPerChannel = 0 }, ErrInvalidBitsPerChannel},
+		{"wrong bytes per packet", func(af *AudioFormat) { af.BytesPerPacket = 6 }, ErrInvalidBytesPerPacket},
+		{"cbr without frames per packet", func(af *AudioFormat) { af.FramesPerPacket = 0 }, ErrInvalidFramesPerPacket},
+	}
+	for _, test := range tests {
+		af := valid
+		test.modify(&af)
+		if err := af.Validate(); err != test.err {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestFormatIDString(t *testing.T) {
+	tests := map[string]string{
+		"lpcm": "Linear PCM",
+		"aac ": "AAC",
+		"alac": "Apple Lossless",
+		".mp1": "MPEG-1 Layer I",
+		".mp2": "MPEG-1 Layer II",
+		".mp3": "MPEG-1 Layer III",
+		"alaw": "A-Law",
+		"ulaw": "µ-Law",
+		"opus": "Opus",
+		"ima4": "Unknown (ima4)",
+	}
+	for formatID, expected := range tests {
+		af := AudioFormat{FormatID: stringToChunkType(formatID)}
+		if name := af.FormatIDString(); name != expected {
+			t.Errorf("%s: expected %q, got %q", formatID, expected, name)
+		}
+	}
+}
+
+func TestAudioFormatEquivalentTo(t *testing.T) {
+	lpcm := AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM, FormatFlags: CAFLinearPCMFormatFlagIsLittleEndian, BytesPerPacket: 4, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: 16}
+	reserved := lpcm
+	reserved.FormatFlags |= 1 << 20
+	if !lpcm.EquivalentTo(reserved) {
+		t.Error("expected formats differing in reserved flags to be equivalent")
+	}
+	float := lpcm
+	float.FormatFlags |= CAFLinearPCMFormatFlagIsFloat
+	if lpcm.EquivalentTo(float) {
+		t.Error("expected float and integer formats to differ")
+	}
+	rate := lpcm
+	rate.SampleRate = 48000
+	if lpcm.EquivalentTo(rate) {
+		t.Error("expected sample rates to differ")
+	}
+	aac := AudioFormat{SampleRate: 44100, FormatID: stringToChunkType("aac "), FormatFlags: 2, FramesPerPacket: 1024, ChannelsPerPacket: 2}
+	otherAAC := aac
+	otherAAC.FormatFlags = 2 | 1<<20
+	if aac.EquivalentTo(otherAAC) {
+		t.Error("expected codec specific flags to be compared exactly")
+	}
+}
+
+func TestAudioFormatSizes(t *testing.T) {
+	lpcm := AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM, BytesPerPacket: 6, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: 24}
+	if lpcm.FrameSize() != 6 || lpcm.PacketSizeBytes() != 6 {
+		t.Errorf("expected 6 byte frames and packets, got %d and %d", lpcm.FrameSize(), lpcm.PacketSizeBytes())
+	}
+	opus := AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2}
+	if opus.FrameSize() != 0 || opus.PacketSizeBytes() != 0 {
+		t.Errorf("expected no fixed sizes for VBR, got %d and %d", opus.FrameSize(), opus.PacketSizeBytes())
+	}
+	if d, err := opus.PacketDuration(); err != nil || d != 20*time.Millisecond {
+		t.Errorf("expected 20ms, got %v (%v)", d, err)
+	}
+	if _, err := (&AudioFormat{FramesPerPacket: 1}).PacketDuration(); err != ErrInvalidSampleRate {
+		t.Errorf("expected ErrInvalidSampleRate, got %v", err)
+	}
+	if _, err := (&AudioFormat{SampleRate: 44100}).PacketDuration(); err != ErrInvalidFramesPerPacket {
+		t.Errorf("expected ErrInvalidFramesPerPacket, got %v", err)
+	}
+}
+
+func TestAudioFormatRequiresPacketTable(t *testing.T) {
+	tests := []struct {
+		af       AudioFormat
+		expected bool
+	}{
+		{AudioFormat{BytesPerPacket: 4, FramesPerPacket: 1}, false},
+		{AudioFormat{BytesPerPacket: 0, FramesPerPacket: 960}, true},
+		{AudioFormat{BytesPerPacket: 4, FramesPerPacket: 0}, true},
+	}
+	for _, test := range tests {
+		if test.af.RequiresPacketTable() != test.expected {
+			t.Errorf("%+v: expected RequiresPacketTable %v", test.af, test.expected)
+		}
+	}
+}
+
+func TestAudioFormatCodecs(t *testing.T) {
+	tests := []struct {
+		formatID string
+		alac     bool
+		aac      bool
+	}{
+		{"alac", true, false},
+		{"aac ", false, true},
+		{"aach", false, true},
+		{"aacp", false, true},
+		{"aace", false, true},
+		{"aacf", false, true},
+		{"aacl", false, true},
+		{"lpcm", false, false},
+		{"opus", false, false},
+	}
+	for _, test := range tests {
+		af := AudioFormat{FormatID: stringToChunkType(test.formatID)}
+		if af.IsALAC() != test.alac {
+			t.Errorf("%q: expected IsALAC %v", test.formatID, test.alac)
+		}
+		if af.IsAAC() != test.aac {
+			t.Errorf("%q: expected IsAAC %v", test.formatID, test.aac)
+		}
+	}
+}
+
+func TestIsALACLogsConstantPacketSize(t *testing.T) {
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+	af := AudioFormat{FormatID: FormatAppleLossless}
+	af.IsALAC()
+	if len(l.messages) != 0 {
+		t.Errorf("expected no log messages, got %v", l.messages)
+	}
+	af.BytesPerPacket = 4096
+	if !af.IsALAC() {
+		t.Error("expected IsALAC")
+	}
+	if len(l.messages) != 1 {
+		t.Errorf("expected 1 log message, got %v", l.messages)
+	}
+}
+
+func TestNewPCMAudioFormats(t *testing.T) {
+	mono, err := NewMonoAudioFormat(44100, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mono.BytesPerPacket != 2 || mono.ChannelsPerPacket != 1 || mono.FormatFlags != 0 || mono.ByteOrderIsLittleEndian() || !mono.SampleFormatIsSignedInteger() {
+		t.Errorf("unexpected mono format %+v", mono)
+	}
+	stereo, err := NewStereoAudioFormat(48000, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stereo.BytesPerPacket != 6 || stereo.ChannelsPerPacket != 2 || stereo.SampleRate != 48000 {
+		t.Errorf("unexpected stereo format %+v", stereo)
+	}
+	for _, af := range []AudioFormat{mono, stereo} {
+		if err := af.Validate(); err != nil {
+			t.Errorf("%+v: %v", af, err)
+		}
+	}
+
+	tests := []struct {
+		sampleRate float64
+		bitDepth   uint32
+		err        error
+	}{
+		{44100, 0, ErrInvalidBitsPerChannel},
+		{44100, 12, ErrInvalidBitsPerChannel},
+		{44100, 72, ErrInvalidBitsPerChannel},
+		{0, 16, ErrInvalidSampleRate},
+	}
+	for _, test := range tests {
+		if _, err := NewStereoAudioFormat(test.sampleRate, test.bitDepth); err != test.err {
+			t.Errorf("%v Hz %d bits: expected %v, got %v", test.sampleRate, test.bitDepth, test.err, err)
+		}
+	}
+}
+
+func TestAudioFormatFrameLayout(t *testing.T) {
+	tests := []struct {
+		name          string
+		af            AudioFormat
+		bitsPerFrame  uint32
+		bytesPerFrame uint32
+		interleaved   bool
+	}{
+		{"16-bit stereo", AudioFormat{BitsPerChannel: 16, ChannelsPerPacket: 2, BytesPerPacket: 4, FramesPerPacket: 1}, 32, 4, true},
+		{"24-bit mono", AudioFormat{BitsPerChannel: 24, ChannelsPerPacket: 1, BytesPerPacket: 3, FramesPerPacket: 1}, 24, 3, true},
+		{"12-bit mono", AudioFormat{BitsPerChannel: 12, ChannelsPerPacket: 1, BytesPerPacket: 2, FramesPerPacket: 1}, 12, 2, true},
+		{"non-interleaved stereo", AudioFormat{BitsPerChannel: 16, ChannelsPerPacket: 2, BytesPerPacket: 2, FramesPerPacket: 1}, 32, 4, false},
+		{"compressed", AudioFormat{ChannelsPerPacket: 2, FramesPerPacket: 960}, 0, 0, true},
+	}
+	for _, test := range tests {
+		if bits := test.af.BitsPerFrame(); bits != test.bitsPerFrame {
+			t.Errorf("%s: expected %d bits per frame, got %d", test.name, test.bitsPerFrame, bits)
+		}
+		if bytes := test.af.BytesPerFrame(); bytes != test.bytesPerFrame {
+			t.Errorf("%s: expected %d bytes per frame, got %d", test.name, test.bytesPerFrame, bytes)
+		}
+		if test.af.IsInterleaved() != test.interleaved {
+			t.Errorf("%s: expected IsInterleaved %v", test.name, test.interleaved)
+		}
+	}
+}
@@ -2,12 +2,14 @@ package caf
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
-
-	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 type FourByteString [4]byte
@@ -18,18 +20,112 @@ var ChunkTypeInformation = stringToChunkType("info")
 var ChunkTypeAudioData = stringToChunkType("data")
 var ChunkTypePacketTable = stringToChunkType("pakt")
 var ChunkTypeMidi = stringToChunkType("midi")
+var ChunkTypeFreeSpace = stringToChunkType("free")
+
+// stringToChunkType converts a four byte literal, panicking on any other
+// length. Use ParseFourByteString for strings from outside the package.
+func stringToChunkType(str string) FourByteString {
+	result, err := ParseFourByteString(str)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// ParseFourByteString converts s to a FourByteString, returning
+// ErrInvalidChunkType unless s is exactly four bytes long.
+func ParseFourByteString(s string) (FourByteString, error) {
+	var result FourByteString
+	if len(s) != len(result) {
+		return result, ErrInvalidChunkType
+	}
+	copy(result[:], s)
+	return result, nil
+}
 
-func stringToChunkType(str string) (result FourByteString) {
-	for i, v := range str {
-		result[i] = byte(v)
+// String returns the four bytes as text, printing any byte outside the
+// printable ASCII range as two hex digits.
+func (s FourByteString) String() string {
+	var sb strings.Builder
+	for _, b := range s {
+		if isPrintableASCII(b) {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%02x", b)
+		}
+	}
+	return sb.String()
+}
+
+// IsASCIIPrintable reports whether all four bytes are printable ASCII.
+func (s FourByteString) IsASCIIPrintable() bool {
+	for _, b := range s {
+		if !isPrintableASCII(b) {
+			return false
+		}
 	}
-	return
+	return true
 }
 
+func isPrintableASCII(b byte) bool {
+	return b >= 0x20 && b <= 0x7e
+}
+
+// MarshalText implements encoding.TextMarshaler. Printable ASCII bytes are
+// written as is and all other bytes, as well as backslashes, as \xNN escapes.
+func (s FourByteString) MarshalText() ([]byte, error) {
+	var text []byte
+	for _, b := range s {
+		if isPrintableASCII(b) && b != '\\' {
+			text = append(text, b)
+		} else {
+			text = append(text, fmt.Sprintf("\\x%02x", b)...)
+		}
+	}
+	return text, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, reversing MarshalText.
+func (s *FourByteString) UnmarshalText(text []byte) error {
+	var result []byte
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' {
+			result = append(result, text[i])
+			continue
+		}
+		if i+3 >= len(text) || text[i+1] != 'x' {
+			return ErrInvalidChunkType
+		}
+		b, err := strconv.ParseUint(string(text[i+2:i+4]), 16, 8)
+		if err != nil {
+			return ErrInvalidChunkType
+		}
+		result = append(result, byte(b))
+		i += 3
+	}
+	parsed, err := ParseFourByteString(string(result))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+const (
+	fileHeaderSize  = 8
+	chunkHeaderSize = 12
+	audioFormatSize = 32
+
+	channelLayoutHeaderSize = 12
+	channelDescriptionSize  = 20
+	// encodeBufferSize is the size of the buffer Encode writes through.
+	encodeBufferSize = 64 * 1024
+)
+
 type FileHeader struct {
-	FileType    FourByteString
-	FileVersion int16
-	FileFlags   int16
+	FileType    FourByteString `json:"fileType"`
+	FileVersion int16          `json:"fileVersion"`
+	FileFlags   int16          `json:"fileFlags"`
 }
 
 type ChunkHeader struct {
@@ -38,33 +134,44 @@ type ChunkHeader struct {
 }
 
 type Data struct {
-	EditCount uint32
-	Data      []byte
+	EditCount uint32 `json:"editCount"`
+	Data      []byte `json:"data"`
 }
 
 type AudioFormat struct {
-	SampleRate        float64
-	FormatID          FourByteString
-	FormatFlags       uint32
-	BytesPerPacket    uint32
-	FramesPerPacket   uint32
-	ChannelsPerPacket uint32
-	BitsPerChannel    uint32
+	SampleRate        float64        `json:"sampleRate"`
+	FormatID          FourByteString `json:"formatID"`
+	FormatFlags       uint32         `json:"formatFlags"`
+	BytesPerPacket    uint32         `json:"bytesPerPacket"`
+	FramesPerPacket   uint32         `json:"framesPerPacket"`
+	ChannelsPerPacket uint32         `json:"channelsPerPacket"`
+	BitsPerChannel    uint32         `json:"bitsPerChannel"`
 }
 
 type PacketTableHeader struct {
-	NumberPackets     int64
-	NumberValidFrames int64
-	PrimingFramess    int32
-	RemainderFrames   int32
+	NumberPackets     int64 `json:"numberPackets"`
+	NumberValidFrames int64 `json:"numberValidFrames"`
+	PrimingFrames     int32 `json:"primingFrames"`
+	RemainderFrames   int32 `json:"remainderFrames"`
 }
 
 type PacketTable struct {
-	Header PacketTableHeader
-	Entry  []uint64
+	Header PacketTableHeader `json:"header"`
+	Entry  []uint64          `json:"entry"`
+
+	// offsets holds a *packetOffsets with the running sums of Entry, see
+	// byteOffset.
+	offsets atomic.Value
 }
 
+// encodeInt writes i to w as a variable length quantity, most significant
+// group first. A nil w writes nothing; use VLQSizeOf to size an encoding.
+// Values above maxVLQValue return ErrVLQOverflow, as decodeInt could not
+// read them back.
 func encodeInt(w io.Writer, i uint64) error {
+	if i > maxVLQValue {
+		return ErrVLQOverflow
+	}
 	var byts []byte
 	var cur = i
 	for {
@@ -93,20 +200,32 @@ func encodeInt(w io.Writer, i uint64) error {
 	return nil
 }
 
+const (
+	maxVLQBytes = 9
+	// maxVLQValue is the largest value that fits in maxVLQBytes groups of
+	// seven bits.
+	maxVLQValue = 1<<(7*maxVLQBytes) - 1
+)
+
 func decodeInt(r *bufio.Reader) (uint64, error) {
 	var res uint64 = 0
 	var bytesRead = 0
 	for {
 		byt, err := r.ReadByte()
-		if err != nil {
+		if err == io.EOF {
+			return 0, ErrUnexpectedEOF
+		} else if err != nil {
 			return 0, err
 		}
 		bytesRead += 1
 		res = res << 7
 		res = res | uint64(byt&127)
-		if byt&128 == 0 || bytesRead >= 8 {
+		if byt&128 == 0 {
 			return res, nil
 		}
+		if bytesRead >= maxVLQBytes {
+			return 0, ErrVLQOverflow
+		}
 	}
 }
 
@@ -114,6 +233,9 @@ func (c *PacketTable) decode(r *bufio.Reader) error {
 	if err := binary.Read(r, binary.BigEndian, &c.Header); err != nil {
 		return err
 	}
+	if c.Header.NumberPackets < 0 || c.Header.NumberValidFrames < 0 {
+		return ErrMalformedPacketTable
+	}
 	for i := 0; i < int(c.Header.NumberPackets); i++ {
 		if val, err := decodeInt(r); err != nil {
 			return err
@@ -124,12 +246,18 @@ func (c *PacketTable) decode(r *bufio.Reader) error {
 	return nil
 }
 
+// encode writes the table, first correcting the header packet count to the
+// number of entries.
 func (c *PacketTable) encode(w io.Writer) error {
+	if n := int64(len(c.Entry)); c.Header.NumberPackets != n {
+		logger.Debugf("Packet table header has %d packets but %d entries, writing %d", c.Header.NumberPackets, n, n)
+		c.Header.NumberPackets = n
+	}
 	if err := binary.Write(w, binary.BigEndian, c.Header); err != nil {
 		return err
 	}
-	for i := 0; i < int(c.Header.NumberPackets); i++ {
-		if err := encodeInt(w, c.Entry[i]); err != nil {
+	for _, entry := range c.Entry {
+		if err := encodeInt(w, entry); err != nil {
 			return err
 		}
 	}
@@ -137,25 +265,29 @@ func (c *PacketTable) encode(w io.Writer) error {
 }
 
 type ChannelLayout struct {
-	ChannelLayoutTag          uint32
-	ChannelBitmap             uint32
-	NumberChannelDescriptions uint32
-	Channels                  []ChannelDescription
+	ChannelLayoutTag          uint32               `json:"channelLayoutTag"`
+	ChannelBitmap             uint32               `json:"channelBitmap"`
+	NumberChannelDescriptions uint32               `json:"numberChannelDescriptions"`
+	Channels                  []ChannelDescription `json:"channels"`
 }
 
 type ChannelDescription struct {
-	ChannelLabel uint32
-	ChannelFlags uint32
-	Coordinates  [3]float32
+	ChannelLabel uint32     `json:"channelLabel"`
+	ChannelFlags uint32     `json:"channelFlags"`
+	Coordinates  [3]float32 `json:"coordinates"`
 }
 
 type Information struct {
-	Key   string
-	Value string
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
+// UnknownContents holds the raw body of a chunk the decoder does not
+// understand. OriginalChunkType records the type the chunk was decoded as,
+// so a chunk retyped through its header can still be told apart.
 type UnknownContents struct {
-	Data []byte
+	OriginalChunkType FourByteString `json:"originalChunkType"`
+	Data              []byte         `json:"data"`
 }
 
 type Midi = []byte
@@ -165,60 +297,264 @@ type File struct {
 	Chunks     []Chunk
 }
 
+// Decode reads a complete CAF file from r. If r is an io.ReadSeeker the
+// chunks record their offsets, as with DecodeFrom.
 func (cf *File) Decode(r io.Reader) error {
-	bufferedReader := bufio.NewReader(r)
+	return cf.DecodeContext(context.Background(), r)
+}
+
+// DecodeFrom reads a complete CAF file from r, which must be positioned at
+// the start of the file, recording in each chunk's Offset where its header
+// starts in r.
+func (cf *File) DecodeFrom(r io.ReadSeeker) error {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	return cf.decode(context.Background(), r, DecodeOptions{}, start)
+}
+
+// DecodeContext decodes like Decode but stops between chunks once ctx is
+// done, returning the context's error and leaving the chunks decoded so far
+// in cf.Chunks.
+func (cf *File) DecodeContext(ctx context.Context, r io.Reader) error {
+	return cf.decode(ctx, r, DecodeOptions{}, streamStart(r))
+}
+
+// DecodeOptions bounds the work done by DecodeWithOptions. The zero value
+// decodes every chunk.
+type DecodeOptions struct {
+	// SkipUnknownChunks discards chunks of types the decoder does not
+	// understand instead of storing them as UnknownContents.
+	SkipUnknownChunks bool
+	// ErrorOnUnknownChunks returns ErrUnknownChunkType for chunks of types
+	// the decoder does not understand.
+	ErrorOnUnknownChunks bool
+	// MaxChunkSize, when positive, skips chunks whose declared size is
+	// larger, including data chunks of unknown size.
+	MaxChunkSize int64
+	// ErrorOnOversizedChunk returns ErrChunkTooLarge instead of skipping
+	// chunks larger than MaxChunkSize.
+	ErrorOnOversizedChunk bool
+	// MaxChunks, when positive, stops decoding once that many chunks have
+	// been stored.
+	MaxChunks int
+	// BufferSize, when positive, sets the size of the read buffer.
+	BufferSize int
+}
+
+// DecodeStrict decodes like Decode but fails with ErrUnknownChunkType on
+// chunks of types the decoder does not understand.
+func (cf *File) DecodeStrict(r io.Reader) error {
+	return cf.DecodeWithOptions(r, DecodeOptions{ErrorOnUnknownChunks: true})
+}
+
+// DecodeWithOptions decodes like Decode, limited by opts.
+func (cf *File) DecodeWithOptions(r io.Reader, opts DecodeOptions) error {
+	return cf.decode(context.Background(), r, opts, streamStart(r))
+}
+
+// DecodeHeaderOnly reads and checks just the file header from r, leaving the
+// chunks that follow it unread.
+func (cf *File) DecodeHeaderOnly(r io.Reader) error {
+	var fileHeader FileHeader
+	if err := fileHeader.Decode(r); err != nil {
+		return err
+	}
+	cf.FileHeader = fileHeader
+	cf.Chunks = nil
+	return nil
+}
+
+// IsCafFile reports whether r starts with the caff file type. Input shorter
+// than that is not an error. Pass a *bufio.Reader to leave its data unread.
+func IsCafFile(r io.Reader) (bool, error) {
+	bufferedReader, ok := r.(*bufio.Reader)
+	if !ok {
+		bufferedReader = bufio.NewReaderSize(r, 16)
+	}
+	b, err := bufferedReader.Peek(4)
+	if err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return FourByteString{b[0], b[1], b[2], b[3]} == stringToChunkType("caff"), nil
+}
+
+// streamStart returns the current position of r, or -1 if r cannot seek.
+func streamStart(r io.Reader) int64 {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	return start
+}
+
+// decode reads the file from r. When start is not negative it is the
+// position of r, and chunks record their offsets relative to it.
+func (cf *File) decode(ctx context.Context, r io.Reader, opts DecodeOptions, start int64) error {
+	cf.FileHeader = FileHeader{}
+	cf.Chunks = nil
+	var bufferedReader *bufio.Reader
+	if opts.BufferSize > 0 {
+		bufferedReader = bufio.NewReaderSize(r, opts.BufferSize)
+	} else {
+		bufferedReader = bufio.NewReader(r)
+	}
 	var fileHeader FileHeader
 	if err := fileHeader.Decode(bufferedReader); err != nil {
 		return err
 	}
 	cf.FileHeader = fileHeader
-	for {
+	offset := int64(fileHeaderSize)
+	for opts.MaxChunks <= 0 || len(cf.Chunks) < opts.MaxChunks {
 		var c Chunk
-		if err := c.decode(bufferedReader); err == io.EOF {
+		if err := c.decodeHeader(bufferedReader); err == io.EOF {
 			break
 		} else if err != nil {
+			return &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: offset, Err: err}
+		}
+		if opts.ErrorOnUnknownChunks && !c.IsKnownType() {
+			return &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: offset, Err: ErrUnknownChunkType}
+		}
+		skip := opts.SkipUnknownChunks && !c.IsKnownType()
+		if opts.MaxChunkSize > 0 && (c.Header.ChunkSize > opts.MaxChunkSize || c.Header.ChunkSize == -1) {
+			if opts.ErrorOnOversizedChunk {
+				return &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: offset, Err: ErrChunkTooLarge}
+			}
+			skip = true
+		}
+		if skip {
+			logger.Debugf("Skipping %v chunk", c.Header.ChunkType)
+			if err := c.skipContents(bufferedReader); err != nil {
+				return &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: offset, Err: err}
+			}
+		} else {
+			if err := c.decodeContents(bufferedReader); err != nil {
+				return &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: offset, Err: err}
+			}
+			if start >= 0 {
+				c.Offset = start + offset
+			}
+			cf.Chunks = append(cf.Chunks, c)
+		}
+		offset += chunkHeaderSize + c.Header.ChunkSize
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		cf.Chunks = append(cf.Chunks, c)
 	}
 	return nil
 }
 
+// Encode writes the file to w, computing each chunk's size from its contents.
+// A file without chunks is not written and returns ErrNoChunks.
 func (cf *File) Encode(w io.Writer) error {
+	return cf.EncodeWithOptions(w, EncodeOptions{AutoComputeChunkSizes: true})
+}
+
+// EncodeOptions controls how EncodeWithOptions writes a file.
+type EncodeOptions struct {
+	// AutoComputeChunkSizes writes each chunk header with the size of the
+	// chunk's encoded contents instead of the stored Header.ChunkSize. A
+	// data chunk with a size of -1 is still written as streaming when it is
+	// the last chunk. Without it, such a chunk anywhere else returns
+	// ErrStreamingDataNotLast.
+	AutoComputeChunkSizes bool
+	// WriteStreamingDataChunk writes the data chunk last with a size of -1,
+	// as used when the length of the audio is not known up front.
+	WriteStreamingDataChunk bool
+}
+
+// EncodeWithOptions encodes like Encode, controlled by opts.
+func (cf *File) EncodeWithOptions(w io.Writer, opts EncodeOptions) error {
+	return cf.encodeBuffered(w, opts, encodeBufferSize)
+}
+
+// EncodeWithBufferSize encodes like Encode but writes through a buffer of
+// bufSize bytes instead of the default 64KB. Small buffers suit writing many
+// small files; a non-positive bufSize uses the default.
+func (cf *File) EncodeWithBufferSize(w io.Writer, bufSize int) error {
+	if bufSize <= 0 {
+		bufSize = encodeBufferSize
+	}
+	return cf.encodeBuffered(w, EncodeOptions{AutoComputeChunkSizes: true}, bufSize)
+}
+
+func (cf *File) encodeBuffered(w io.Writer, opts EncodeOptions, bufSize int) error {
+	bufferedWriter := bufio.NewWriterSize(w, bufSize)
+	if err := cf.encode(bufferedWriter, opts); err != nil {
+		return err
+	}
+	return bufferedWriter.Flush()
+}
+
+func (cf *File) encode(w io.Writer, opts EncodeOptions) error {
+	if len(cf.Chunks) == 0 {
+		return ErrNoChunks
+	}
+	chunks := cf.Chunks
+	if opts.WriteStreamingDataChunk {
+		chunks = make([]Chunk, 0, len(cf.Chunks))
+		var dataChunk *Chunk
+		for i, c := range cf.Chunks {
+			if c.Header.ChunkType != ChunkTypeAudioData {
+				chunks = append(chunks, c)
+			} else if dataChunk != nil {
+				return ErrMultipleAudioData
+			} else {
+				dataChunk = &cf.Chunks[i]
+			}
+		}
+		if dataChunk != nil {
+			streaming := *dataChunk
+			streaming.Header.ChunkSize = -1
+			chunks = append(chunks, streaming)
+		}
+	}
 	if err := cf.FileHeader.Encode(w); err != nil {
 		return err
 	}
-	for _, c := range cf.Chunks {
-		if err := c.Encode(w); err != nil {
+	for i, c := range chunks {
+		if c.Header.ChunkType == ChunkTypeAudioData && c.Header.ChunkSize == -1 && i != len(chunks)-1 {
+			// A size of -1 means the audio runs to the end of the file, so
+			// a streaming data chunk followed by other chunks needs its
+			// real size.
+			if !opts.AutoComputeChunkSizes {
+				return ErrStreamingDataNotLast
+			}
+			size, err := c.ContentEncodedSize()
+			if err != nil {
+				return err
+			}
+			c.Header.ChunkSize = size
+		}
+		if err := c.encode(w, opts.AutoComputeChunkSizes); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func readString(r io.Reader) (string, error) {
-	var bs []byte
-	var b = make([]byte, 1)
-	for {
-		if _, err := r.Read(b); err != nil {
-			return "", err
-		} else {
-			bs = append(bs, b[0])
-			if b[0] == 0 {
-				break
-			}
-		}
+func readString(r *bufio.Reader) (string, error) {
+	bs, err := r.ReadBytes(0)
+	if err != nil {
+		return "", err
 	}
-	return string(bs), nil
+	return string(bs[:len(bs)-1]), nil
 }
 
 func writeString(w io.Writer, s string) error {
-	byteString := []byte(s)
+	byteString := append([]byte(s), 0)
 	_, err := w.Write(byteString)
 	return err
 }
 
-func (c *Information) decode(r io.Reader) error {
+func (c *Information) decode(r *bufio.Reader) error {
 	if key, err := readString(r); err != nil {
 		return err
 	} else {
@@ -240,7 +576,7 @@ func (c *Information) encode(w io.Writer) error {
 	return writeString(w, c.Value)
 }
 
-func (c *CAFStringsChunk) decode(r io.Reader) error {
+func (c *CAFStringsChunk) decode(r *bufio.Reader) error {
 	if err := binary.Read(r, binary.BigEndian, &c.NumEntries); err != nil {
 		return err
 	}
@@ -255,10 +591,13 @@ func (c *CAFStringsChunk) decode(r io.Reader) error {
 }
 
 func (c *CAFStringsChunk) encode(w io.Writer) error {
-	if err := binary.Write(w, binary.BigEndian, &c.NumEntries); err != nil {
+	if c.NumEntries != uint32(len(c.Strings)) {
+		logger.Debugf("Information chunk has %d entries but NumEntries is %d, writing %d", len(c.Strings), c.NumEntries, len(c.Strings))
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(c.Len())); err != nil {
 		return err
 	}
-	for i := uint32(0); i < c.NumEntries; i++ {
+	for i := range c.Strings {
 		if err := c.Strings[i].encode(w); err != nil {
 			return err
 		}
@@ -267,13 +606,16 @@ func (c *CAFStringsChunk) encode(w io.Writer) error {
 }
 
 type CAFStringsChunk struct {
-	NumEntries uint32
-	Strings    []Information
+	NumEntries uint32        `json:"numEntries"`
+	Strings    []Information `json:"strings"`
 }
 
 type Chunk struct {
 	Header   ChunkHeader
 	Contents interface{}
+	// Offset is the position of the chunk header in the stream the chunk
+	// was decoded from. It is zero unless that stream could seek.
+	Offset int64
 }
 
 func (c *AudioFormat) decode(r io.Reader) error {
@@ -322,20 +664,33 @@ func (c *ChannelLayout) encode(w io.Writer) error {
 	return nil
 }
 
+// readChunkBytes reads size bytes from r. The buffer grows as data arrives,
+// so a corrupt size cannot force a huge allocation up front.
+func readChunkBytes(r io.Reader, size int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) < size {
+		return nil, ErrUnexpectedEOF
+	}
+	return b, nil
+}
+
 func (c *Data) decode(r *bufio.Reader, h ChunkHeader) error {
 	if err := binary.Read(r, binary.BigEndian, &c.EditCount); err != nil {
 		return err
 	}
 	if h.ChunkSize == -1 {
 		// read until end
-		data, err := ioutil.ReadAll(r)
+		data, err := io.ReadAll(r)
 		if err != nil {
 			return err
 		}
 		c.Data = data
 	} else {
 		dataLength := h.ChunkSize - 4 /* for edit count*/
-		data, err := ioutil.ReadAll(io.LimitReader(r, dataLength))
+		data, err := readChunkBytes(r, dataLength)
 		if err != nil {
 			return err
 		}
@@ -348,16 +703,74 @@ func (c *Data) encode(w io.Writer) error {
 	if err := binary.Write(w, binary.BigEndian, &c.EditCount); err != nil {
 		return err
 	}
-	if err := binary.Write(w, binary.BigEndian, &c.Data); err != nil {
+	if n, err := w.Write(c.Data); err != nil {
 		return err
+	} else if n != len(c.Data) {
+		return io.ErrShortWrite
 	}
 	return nil
 }
 
+func isKnownChunkType(t FourByteString) bool {
+	switch t {
+	case ChunkTypeAudioDescription,
+		ChunkTypeChannelLayout,
+		ChunkTypeInformation,
+		ChunkTypeAudioData,
+		ChunkTypePacketTable,
+		ChunkTypeMidi,
+		ChunkTypeFreeSpace,
+		ChunkTypeMarkers,
+		ChunkTypeRegion,
+		ChunkTypePeak:
+		return true
+	}
+	return false
+}
+
 func (c *Chunk) decode(r *bufio.Reader) error {
+	if err := c.decodeHeader(r); err != nil {
+		return err
+	}
+	return c.decodeContents(r)
+}
+
+func (c *Chunk) decodeHeader(r io.Reader) error {
 	if err := binary.Read(r, binary.BigEndian, &c.Header); err != nil {
 		return err
 	}
+	if c.Header.ChunkSize < 0 && !(c.Header.ChunkSize == -1 && c.Header.ChunkType == ChunkTypeAudioData) {
+		return ErrInvalidChunkSize
+	}
+	return nil
+}
+
+func (c *Chunk) skipContents(r *bufio.Reader) error {
+	if c.Header.ChunkSize == -1 {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, r, c.Header.ChunkSize); err == io.EOF {
+		return ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// decodeContents reads the chunk body. Running out of input partway through
+// a body is reported as ErrUnexpectedEOF rather than io.EOF, which callers
+// treat as the clean end of the file.
+func (c *Chunk) decodeContents(r *bufio.Reader) error {
+	if err := c.readContents(r); err == io.EOF {
+		return ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Chunk) readContents(r *bufio.Reader) error {
 	switch c.Header.ChunkType {
 	case ChunkTypeAudioDescription:
 		{
@@ -404,31 +817,83 @@ func (c *Chunk) decode(r *bufio.Reader) error {
 		}
 	case ChunkTypeMidi:
 		{
-			var cc Midi
-			ba := make([]byte, c.Header.ChunkSize)
-			if err := binary.Read(r, binary.BigEndian, &ba); err != nil {
+			cc, err := readChunkBytes(r, c.Header.ChunkSize)
+			if err != nil {
+				return err
+			}
+			c.Contents = Midi(cc)
+		}
+	case ChunkTypeFreeSpace:
+		{
+			var cc FreeSpaceChunk
+			if err := cc.decode(r, c.Header); err != nil {
+				return err
+			}
+			c.Contents = &cc
+		}
+	case ChunkTypeMarkers:
+		{
+			var cc MarkerChunk
+			if err := cc.decode(r); err != nil {
+				return err
+			}
+			c.Contents = &cc
+		}
+	case ChunkTypeRegion:
+		{
+			var cc RegionChunk
+			if err := cc.decode(r); err != nil {
 				return err
 			}
-			cc = ba
-			c.Contents = cc
+			c.Contents = &cc
+		}
+	case ChunkTypePeak:
+		{
+			var cc PeakChunk
+			if err := cc.decode(r, c.Header); err != nil {
+				return err
+			}
+			c.Contents = &cc
 		}
 	default:
 		{
-			logrus.Debugf("Got unknown chunk type")
-			ba := make([]byte, c.Header.ChunkSize)
-			if err := binary.Read(r, binary.BigEndian, &ba); err != nil {
+			logger.Debugf("Got unknown chunk type %v", c.Header.ChunkType)
+			ba, err := readChunkBytes(r, c.Header.ChunkSize)
+			if err != nil {
 				return err
 			}
-			c.Contents = &UnknownContents{Data: ba}
+			c.Contents = &UnknownContents{OriginalChunkType: c.Header.ChunkType, Data: ba}
 		}
 	}
 	return nil
 }
 
 func (c *Chunk) Encode(w io.Writer) error {
-	if err := binary.Write(w, binary.BigEndian, &c.Header); err != nil {
+	return c.encode(w, true)
+}
+
+func (c *Chunk) encode(w io.Writer, autoComputeSize bool) error {
+	if !autoComputeSize {
+		if err := binary.Write(w, binary.BigEndian, &c.Header); err != nil {
+			return err
+		}
+		return c.encodeContents(w)
+	}
+	header := c.Header
+	if header.ChunkSize != -1 || header.ChunkType != ChunkTypeAudioData {
+		size, err := c.ContentEncodedSize()
+		if err != nil {
+			return err
+		}
+		header.ChunkSize = size
+	}
+	if err := binary.Write(w, binary.BigEndian, &header); err != nil {
 		return err
 	}
+	return c.encodeContents(w)
+}
+
+func (c *Chunk) encodeContents(w io.Writer) error {
 	switch c.Header.ChunkType {
 	case ChunkTypeAudioDescription:
 		{
@@ -460,7 +925,6 @@ func (c *Chunk) Encode(w io.Writer) error {
 			if err := cc.encode(w); err != nil {
 				return err
 			}
-			c.Contents = &cc
 		}
 	case ChunkTypePacketTable:
 		{
@@ -468,7 +932,6 @@ func (c *Chunk) Encode(w io.Writer) error {
 			if err := cc.encode(w); err != nil {
 				return err
 			}
-			c.Contents = &cc
 		}
 	case ChunkTypeMidi:
 		{
@@ -478,6 +941,34 @@ func (c *Chunk) Encode(w io.Writer) error {
 			}
 
 		}
+	case ChunkTypeFreeSpace:
+		{
+			cc := c.Contents.(*FreeSpaceChunk)
+			if err := cc.encode(w); err != nil {
+				return err
+			}
+		}
+	case ChunkTypeMarkers:
+		{
+			cc := c.Contents.(*MarkerChunk)
+			if err := cc.encode(w); err != nil {
+				return err
+			}
+		}
+	case ChunkTypeRegion:
+		{
+			cc := c.Contents.(*RegionChunk)
+			if err := cc.encode(w); err != nil {
+				return err
+			}
+		}
+	case ChunkTypePeak:
+		{
+			cc := c.Contents.(*PeakChunk)
+			if err := cc.encode(w); err != nil {
+				return err
+			}
+		}
 	default:
 		{
 			data := c.Contents.(*UnknownContents).Data
@@ -491,11 +982,26 @@ func (c *Chunk) Encode(w io.Writer) error {
 
 func (h *FileHeader) Decode(r io.Reader) error {
 	err := binary.Read(r, binary.BigEndian, h)
-	if err != nil {
+	if err == io.EOF {
+		return ErrUnexpectedEOF
+	} else if err != nil {
 		return err
 	}
+	return h.Validate()
+}
+
+// NewFileHeader returns the header of a version 1 CAF file.
+func NewFileHeader() FileHeader {
+	return FileHeader{FileType: stringToChunkType("caff"), FileVersion: 1}
+}
+
+// Validate checks the file type and version.
+func (h *FileHeader) Validate() error {
 	if h.FileType != stringToChunkType("caff") {
-		return errors.New("invalid caff header")
+		return ErrInvalidFileType
+	}
+	if h.FileVersion != 1 {
+		return ErrUnsupportedFileVersion
 	}
 	return nil
 }
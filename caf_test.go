@@ -1,13 +1,23 @@
 package caf
 
 import (
+	"bufio"
 	"bytes"
-	"io/ioutil"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestBasicHelenKane(t *testing.T) {
-	contents, err := ioutil.ReadFile("samples/helenkane.caf")
+	contents, err := os.ReadFile("samples/helenkane.caf")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -27,6 +37,17 @@ func TestBasicHelenKane(t *testing.T) {
 		t.Errorf("contents of input differ when decoding and reencoding, before: %d after: %d",
 			len(contents),
 			outputBuffer.Len())
+		reencoded := &File{}
+		if err := reencoded.Decode(bytes.NewReader(outputBuffer.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		diffs, err := f.Diff(reencoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range diffs {
+			t.Log(d)
+		}
 	}
 	output := outputBuffer.Bytes()
 	for i := 0; i < len(contents); i++ {
@@ -35,4 +56,631 @@ func TestBasicHelenKane(t *testing.T) {
 			break
 		}
 	}
-}
\ No newline at end of file
+}
+
+type shortWriter struct {
+	w io.Writer
+}
+
+func (s shortWriter) Write(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:len(p)-1]
+	}
+	return s.w.Write(p)
+}
+
+func TestDataEncodeShortWrite(t *testing.T) {
+	d := &Data{Data: []byte{1, 2, 3, 4}}
+	if err := d.encode(shortWriter{&bytes.Buffer{}}); err != io.ErrShortWrite {
+		t.Errorf("expected io.ErrShortWrite, got %v", err)
+	}
+}
+
+func TestEncodeReturnsFlushError(t *testing.T) {
+	if err := testFile().Encode(shortWriter{&bytes.Buffer{}}); err != io.ErrShortWrite {
+		t.Errorf("expected io.ErrShortWrite, got %v", err)
+	}
+}
+
+func TestEncodeEmptyFile(t *testing.T) {
+	f := &File{FileHeader: NewFileHeader()}
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != ErrNoChunks {
+		t.Errorf("expected ErrNoChunks, got %v", err)
+	}
+	if err := f.EncodeWithOptions(buf, EncodeOptions{WriteStreamingDataChunk: true}); err != ErrNoChunks {
+		t.Errorf("expected ErrNoChunks from EncodeWithOptions, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %d bytes", buf.Len())
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader([]byte("caff\x00\x01\x00\x00"))); err != nil || len(decoded.Chunks) != 0 {
+		t.Errorf("expected a header-only file to decode with no chunks, got %d chunks (%v)", len(decoded.Chunks), err)
+	}
+}
+
+func TestDataExplicitChunkSize(t *testing.T) {
+	f := testFile()
+	outputBuffer := &bytes.Buffer{}
+	if err := f.Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader(outputBuffer.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := decoded.ChunkByType(ChunkTypeAudioData)
+	if !ok {
+		t.Fatal("expected data chunk")
+	}
+	if c.Header.ChunkSize != 12 {
+		t.Errorf("expected data chunk size 12, got %d", c.Header.ChunkSize)
+	}
+	if data := c.Contents.(*Data).Data; len(data) != 8 {
+		t.Errorf("expected 8 bytes of audio data, got %d", len(data))
+	}
+}
+
+func TestEncodeComputesChunkSizes(t *testing.T) {
+	f := testFile()
+	for i := range f.Chunks {
+		f.Chunks[i].Header.ChunkSize = 0
+	}
+	outputBuffer := &bytes.Buffer{}
+	if err := f.Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader(outputBuffer.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	expected := []int64{32, 2, 1, 12}
+	if len(decoded.Chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(decoded.Chunks))
+	}
+	for i, size := range expected {
+		if got := decoded.Chunks[i].Header.ChunkSize; got != size {
+			t.Errorf("chunk %d: expected size %d, got %d", i, size, got)
+		}
+	}
+}
+
+func TestEncodeStreamingDataChunk(t *testing.T) {
+	f := testFile()
+	f.Chunks[3].Header.ChunkSize = -1
+	outputBuffer := &bytes.Buffer{}
+	if err := f.Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader(outputBuffer.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := decoded.ChunkByType(ChunkTypeAudioData)
+	if c.Header.ChunkSize != -1 {
+		t.Errorf("expected streaming data chunk size -1, got %d", c.Header.ChunkSize)
+	}
+	if data := c.Contents.(*Data).Data; len(data) != 8 {
+		t.Errorf("expected 8 bytes of audio data, got %d", len(data))
+	}
+}
+
+func TestEncodeStreamingDataChunkNotLast(t *testing.T) {
+	streaming := &bytes.Buffer{}
+	if err := testFile().EncodeWithOptions(streaming, EncodeOptions{AutoComputeChunkSizes: true, WriteStreamingDataChunk: true}); err != nil {
+		t.Fatal(err)
+	}
+	f := &File{}
+	if err := f.Decode(bytes.NewReader(streaming.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	f.Chunks = append(f.Chunks, NewMidiChunk([]byte{4, 5, 6}))
+	if err := f.EncodeWithOptions(&bytes.Buffer{}, EncodeOptions{}); err != ErrStreamingDataNotLast {
+		t.Errorf("expected ErrStreamingDataNotLast with stored sizes, got %v", err)
+	}
+	outputBuffer := &bytes.Buffer{}
+	if err := f.Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader(outputBuffer.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if types := chunkTypes(decoded); len(types) != 5 || types[4] != ChunkTypeMidi {
+		t.Fatalf("expected appended midi chunk to survive, got %v", types)
+	}
+	c, _ := decoded.ChunkByType(ChunkTypeAudioData)
+	if c.Header.ChunkSize != 12 {
+		t.Errorf("expected data chunk size 12, got %d", c.Header.ChunkSize)
+	}
+	if data := c.Contents.(*Data).Data; len(data) != 8 {
+		t.Errorf("expected 8 bytes of audio data, got %d", len(data))
+	}
+	if err := f.VerifyRoundTrip(); err != nil {
+		t.Errorf("expected file to round trip, got %v", err)
+	}
+}
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestSetLogger(t *testing.T) {
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+	f := testFile()
+	f.Chunks = append(f.Chunks, Chunk{
+		Header:   ChunkHeader{ChunkType: stringToChunkType("zzzz")},
+		Contents: &UnknownContents{Data: []byte{1}},
+	})
+	outputBuffer := &bytes.Buffer{}
+	if err := f.Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&File{}).Decode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.messages) != 1 {
+		t.Errorf("expected 1 log message, got %d", len(l.messages))
+	}
+}
+
+func TestFourByteStringString(t *testing.T) {
+	tests := []struct {
+		in        FourByteString
+		out       string
+		printable bool
+	}{
+		{ChunkTypeAudioDescription, "desc", true},
+		{stringToChunkType("aac "), "aac ", true},
+		{FourByteString{'a', 0, 'b', 0xff}, "a00bff", false},
+	}
+	for _, test := range tests {
+		if s := fmt.Sprintf("%v", test.in); s != test.out {
+			t.Errorf("expected %q, got %q", test.out, s)
+		}
+		if p := test.in.IsASCIIPrintable(); p != test.printable {
+			t.Errorf("%q: expected printable %v, got %v", test.out, test.printable, p)
+		}
+	}
+}
+
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	if len(p) > 4 {
+		p = p[:4]
+	}
+	return s.r.Read(p)
+}
+
+func TestDecodeContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r := slowReader{bytes.NewReader(encodedTestFile(t)), 5 * time.Millisecond}
+	f := &File{}
+	if err := f.DecodeContext(ctx, r); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(f.Chunks) == 0 || len(f.Chunks) >= len(testFile().Chunks) {
+		t.Errorf("expected partially decoded chunks, got %d", len(f.Chunks))
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	f := &File{}
+	if err := f.DecodeContext(context.Background(), bytes.NewReader(encodedTestFile(t))); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Chunks) != len(testFile().Chunks) {
+		t.Errorf("expected %d chunks, got %d", len(testFile().Chunks), len(f.Chunks))
+	}
+}
+
+func TestDecodeInt(t *testing.T) {
+	tests := []struct {
+		in  []byte
+		out uint64
+		err error
+	}{
+		{[]byte{0x00}, 0, nil},
+		{[]byte{0x7f}, 127, nil},
+		{[]byte{0x81, 0x00}, 128, nil},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 1<<63 - 1, nil},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 0, ErrVLQOverflow},
+		{[]byte{0x81}, 0, io.ErrUnexpectedEOF},
+		{nil, 0, io.ErrUnexpectedEOF},
+	}
+	for _, test := range tests {
+		v, err := decodeInt(bufio.NewReader(bytes.NewReader(test.in)))
+		if err != test.err {
+			t.Errorf("%x: expected error %v, got %v", test.in, test.err, err)
+		} else if v != test.out {
+			t.Errorf("%x: expected %d, got %d", test.in, test.out, v)
+		}
+	}
+}
+
+func TestEncodeIntRoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 16383, 16384, 1<<32 + 5, 1<<63 - 1} {
+		buf := &bytes.Buffer{}
+		if err := encodeInt(buf, v); err != nil {
+			t.Fatal(err)
+		}
+		if decoded, err := decodeInt(bufio.NewReader(buf)); err != nil {
+			t.Fatal(err)
+		} else if decoded != v {
+			t.Errorf("expected %d, got %d", v, decoded)
+		}
+	}
+	for _, v := range []uint64{1 << 63, 1<<64 - 1} {
+		buf := &bytes.Buffer{}
+		if err := encodeInt(buf, v); err != ErrVLQOverflow {
+			t.Errorf("%d: expected ErrVLQOverflow, got %v", v, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%d: expected nothing written, got %d bytes", v, buf.Len())
+		}
+	}
+	f := vbrTestFile()
+	pt, _ := f.Chunks[f.IndexOfChunk(ChunkTypePacketTable)].AsPacketTable()
+	pt.Entry[0] = 1 << 63
+	if err := f.Encode(io.Discard); !errors.Is(err, ErrVLQOverflow) {
+		t.Errorf("expected ErrVLQOverflow encoding an oversized packet table entry, got %v", err)
+	}
+}
+
+func TestInformationRoundTrip(t *testing.T) {
+	f := testFile()
+	f.Chunks = append(f.Chunks, Chunk{
+		Header: ChunkHeader{ChunkType: ChunkTypeInformation},
+		Contents: &CAFStringsChunk{NumEntries: 2, Strings: []Information{
+			{Key: "artist", Value: "Helen Kane"},
+			{Key: "title", Value: "I Wanna Be Loved By You"},
+		}},
+	})
+	outputBuffer := &bytes.Buffer{}
+	if err := f.Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := decoded.ChunkByType(ChunkTypeInformation)
+	if !ok {
+		t.Fatal("expected info chunk")
+	}
+	strings := c.Contents.(*CAFStringsChunk).Strings
+	expected := f.Chunks[len(f.Chunks)-1].Contents.(*CAFStringsChunk).Strings
+	if len(strings) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(strings))
+	}
+	for i := range expected {
+		if strings[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], strings[i])
+		}
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	f := allChunkTypesFile(t)
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	allTypes := chunkTypes(f)
+	var withoutUnknown, withoutLarge []FourByteString
+	for _, c := range f.Chunks {
+		if isKnownChunkType(c.Header.ChunkType) {
+			withoutUnknown = append(withoutUnknown, c.Header.ChunkType)
+		}
+		if c.Header.ChunkSize <= 31 {
+			withoutLarge = append(withoutLarge, c.Header.ChunkType)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		opts  DecodeOptions
+		types []FourByteString
+		err   error
+	}{
+		{"defaults", DecodeOptions{}, allTypes, nil},
+		{"skip unknown chunks", DecodeOptions{SkipUnknownChunks: true}, withoutUnknown, nil},
+		{"max chunk size", DecodeOptions{MaxChunkSize: 31}, withoutLarge, nil},
+		{"max chunk size error", DecodeOptions{MaxChunkSize: 31, ErrorOnOversizedChunk: true}, nil, ErrChunkTooLarge},
+		{"max chunks", DecodeOptions{MaxChunks: 2}, allTypes[:2], nil},
+		{"buffer size", DecodeOptions{BufferSize: 16}, allTypes, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded := &File{}
+			err := decoded.DecodeWithOptions(bytes.NewReader(encoded), test.opts)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(chunkTypes(decoded), test.types) {
+				t.Errorf("expected chunks %v, got %v", test.types, chunkTypes(decoded))
+			}
+		})
+	}
+}
+
+func TestParseFourByteString(t *testing.T) {
+	if fbs, err := ParseFourByteString("desc"); err != nil || fbs != ChunkTypeAudioDescription {
+		t.Errorf("expected desc, got %v (%v)", fbs, err)
+	}
+	if fbs, err := ParseFourByteString("aac "); err != nil || fbs != (FourByteString{'a', 'a', 'c', ' '}) {
+		t.Errorf("expected \"aac \", got %v (%v)", fbs, err)
+	}
+	for _, s := range []string{"", "aac", "chunk", "dé"} {
+		if _, err := ParseFourByteString(s); err != ErrInvalidChunkType {
+			t.Errorf("%q: expected ErrInvalidChunkType, got %v", s, err)
+		}
+	}
+	if fbs, err := ParseFourByteString("é€"); err != ErrInvalidChunkType {
+		t.Errorf("expected five byte string to be rejected, got %v", fbs)
+	}
+}
+
+func TestStringToChunkTypePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for three byte chunk type")
+		}
+	}()
+	stringToChunkType("aac")
+}
+
+func TestDecodeResetsFile(t *testing.T) {
+	f := &File{}
+	if err := f.Decode(bytes.NewReader(encodedTestFile(t))); err != nil {
+		t.Fatal(err)
+	}
+	second := NewFile(AudioFormat{SampleRate: 8000, FormatID: FormatLinearPCM, BytesPerPacket: 1, FramesPerPacket: 1, ChannelsPerPacket: 1, BitsPerChannel: 8})
+	buf := &bytes.Buffer{}
+	if err := second.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Chunks) != len(second.Chunks) {
+		t.Errorf("expected %d chunks, got %d", len(second.Chunks), len(f.Chunks))
+	}
+	if err := f.Decode(bytes.NewReader([]byte("RIFF"))); err == nil {
+		t.Fatal("expected error decoding invalid header")
+	}
+	if len(f.Chunks) != 0 || f.FileHeader != (FileHeader{}) {
+		t.Errorf("expected failed decode to leave an empty file, got %+v", f)
+	}
+}
+
+func TestFourByteStringText(t *testing.T) {
+	af := AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM}
+	encoded, err := json.Marshal(af)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(encoded, []byte(`"formatID":"lpcm"`)) {
+		t.Errorf("expected readable format id, got %s", encoded)
+	}
+	var decoded AudioFormat
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != af {
+		t.Errorf("expected %+v, got %+v", af, decoded)
+	}
+
+	for _, fbs := range []FourByteString{{'a', 0, '\\', 0xff}, {'x', '\\', 'x', '1'}} {
+		text, err := fbs.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed FourByteString
+		if err := parsed.UnmarshalText(text); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if parsed != fbs {
+			t.Errorf("%s: expected %v, got %v", text, fbs, parsed)
+		}
+	}
+	if text, _ := (FourByteString{'a', 0, 'b', 0xff}).MarshalText(); string(text) != `a\x00b\xff` {
+		t.Errorf("unexpected escaped text %s", text)
+	}
+
+	for _, text := range []string{"lpc", "lpcmm", `lpc\x`, `lpc\xzz`, `lpc\q00`} {
+		var parsed FourByteString
+		if err := parsed.UnmarshalText([]byte(text)); err != ErrInvalidChunkType {
+			t.Errorf("%s: expected ErrInvalidChunkType, got %v", text, err)
+		}
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+	f := testFile()
+	f.Chunks[0].Header.ChunkSize = 0
+	f.Chunks = append(f.Chunks[:3], f.Chunks[3], Chunk{Header: ChunkHeader{ChunkType: ChunkTypeMidi, ChunkSize: 1}, Contents: Midi{4}})
+
+	buf := &bytes.Buffer{}
+	if err := f.EncodeWithOptions(buf, EncodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if size := int64(binary.BigEndian.Uint64(buf.Bytes()[fileHeaderSize+4:])); size != 0 {
+		t.Errorf("expected stored chunk size 0 to be written, got %d", size)
+	}
+
+	buf.Reset()
+	if err := f.EncodeWithOptions(buf, EncodeOptions{AutoComputeChunkSizes: true, WriteStreamingDataChunk: true}); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := []FourByteString{ChunkTypeAudioDescription, ChunkTypeMidi, ChunkTypeMidi, ChunkTypeMidi, ChunkTypeAudioData}
+	if !reflect.DeepEqual(chunkTypes(decoded), expected) {
+		t.Fatalf("expected chunks %v, got %v", expected, chunkTypes(decoded))
+	}
+	if size := decoded.Chunks[0].Header.ChunkSize; size != 32 {
+		t.Errorf("expected computed desc size 32, got %d", size)
+	}
+	if size := decoded.Chunks[4].Header.ChunkSize; size != -1 {
+		t.Errorf("expected streaming data chunk, got size %d", size)
+	}
+	if f.Chunks[3].Header.ChunkSize != 12 {
+		t.Error("expected encoding to leave the file unchanged")
+	}
+
+	f.Chunks = append(f.Chunks, f.Chunks[3])
+	if err := f.EncodeWithOptions(&bytes.Buffer{}, EncodeOptions{WriteStreamingDataChunk: true}); err != ErrMultipleAudioData {
+		t.Errorf("expected ErrMultipleAudioData, got %v", err)
+	}
+}
+
+// largeTestFile returns a VBR file holding size bytes of audio split into
+// 1KB packets.
+func largeTestFile(size int) *File {
+	f := NewFile(AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2})
+	pt, _ := f.Chunks[1].AsPacketTable()
+	for i := 0; i < size/1024; i++ {
+		pt.AddEntry(1024)
+	}
+	pt.Header.NumberValidFrames = pt.Header.NumberPackets * 960
+	f.Chunks[1].Header.ChunkSize = int64(pt.EncodedSize())
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypeAudioData, ChunkSize: 4 + int64(size)}, Contents: &Data{Data: make([]byte, size)}})
+	return f
+}
+
+func benchmarkEncode(b *testing.B, encode func(f *File, w io.Writer) error) {
+	f := largeTestFile(1 << 20)
+	out, err := os.Create(filepath.Join(b.TempDir(), "bench.caf"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer out.Close()
+	b.SetBytes(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := out.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		if err := encode(f, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The benchmarks write stored chunk sizes so that every header field and
+// packet table entry reaches the writer as its own small write. For the 1MB
+// file either buffer size encodes about ten times faster than no buffer.
+
+func BenchmarkEncodeUnbuffered(b *testing.B) {
+	benchmarkEncode(b, func(f *File, w io.Writer) error {
+		return f.encode(w, EncodeOptions{})
+	})
+}
+
+func BenchmarkEncodeBuffered4KB(b *testing.B) {
+	benchmarkEncode(b, func(f *File, w io.Writer) error {
+		return f.encodeBuffered(w, EncodeOptions{}, 4<<10)
+	})
+}
+
+func BenchmarkEncodeBuffered64KB(b *testing.B) {
+	benchmarkEncode(b, func(f *File, w io.Writer) error {
+		return f.encodeBuffered(w, EncodeOptions{}, 64<<10)
+	})
+}
+
+func TestEncodeWithBufferSize(t *testing.T) {
+	expected := encodedTestFile(t)
+	for _, size := range []int{-1, 0, 16, 4 << 10} {
+		buf := &bytes.Buffer{}
+		if err := testFile().EncodeWithBufferSize(buf, size); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("%d: expected the same bytes as Encode", size)
+		}
+	}
+}
+
+func TestDecodeHeaderOnly(t *testing.T) {
+	r := bytes.NewReader(encodedTestFile(t))
+	f := &File{Chunks: testFile().Chunks}
+	if err := f.DecodeHeaderOnly(r); err != nil {
+		t.Fatal(err)
+	}
+	if f.FileHeader != testFile().FileHeader || f.Chunks != nil {
+		t.Errorf("unexpected file %+v", f)
+	}
+	if r.Len() != len(encodedTestFile(t))-fileHeaderSize {
+		t.Errorf("expected only the header to be read, %d bytes left", r.Len())
+	}
+	if err := f.DecodeHeaderOnly(bytes.NewReader([]byte("caff"))); err != ErrUnexpectedEOF {
+		t.Errorf("expected ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestIsCafFile(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected bool
+	}{
+		{encodedTestFile(t), true},
+		{[]byte("caff"), true},
+		{[]byte("RIFF\x00\x00\x00\x00"), false},
+		{[]byte("caf"), false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		isCaf, err := IsCafFile(bytes.NewReader(test.input))
+		if err != nil || isCaf != test.expected {
+			t.Errorf("%q: expected %v, got %v (%v)", test.input, test.expected, isCaf, err)
+		}
+	}
+	r := bufio.NewReader(bytes.NewReader(encodedTestFile(t)))
+	if isCaf, _ := IsCafFile(r); !isCaf {
+		t.Fatal("expected caf file")
+	}
+	if _, err := Decode(r); err != nil {
+		t.Errorf("expected peeked reader to still decode, got %v", err)
+	}
+}
+
+func TestFileHeaderValidate(t *testing.T) {
+	h := NewFileHeader()
+	if err := h.Validate(); err != nil {
+		t.Errorf("expected new header to be valid, got %v", err)
+	}
+	if h.FileFlags != 0 {
+		t.Errorf("expected no flags, got %d", h.FileFlags)
+	}
+	invalidType := NewFileHeader()
+	invalidType.FileType = stringToChunkType("RIFF")
+	if err := invalidType.Validate(); err != ErrInvalidFileType {
+		t.Errorf("expected ErrInvalidFileType, got %v", err)
+	}
+	invalidVersion := NewFileHeader()
+	invalidVersion.FileVersion = 2
+	if err := invalidVersion.Validate(); err != ErrUnsupportedFileVersion {
+		t.Errorf("expected ErrUnsupportedFileVersion, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package caf
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Channel layout tags from CoreAudioBaseTypes.h (kAudioChannelLayoutTag_*).
+// The low 16 bits of a tag hold its channel count. The surround tags are the
+// MPEG "A" orderings.
+const (
+	ChannelLayoutTagUseChannelDescriptions uint32 = 0<<16 | 0
+	ChannelLayoutTagUseChannelBitmap       uint32 = 1<<16 | 0
+	ChannelLayoutTagMono                   uint32 = 100<<16 | 1
+	ChannelLayoutTagStereo                 uint32 = 101<<16 | 2
+	ChannelLayoutTagBinaural               uint32 = 106<<16 | 2
+	ChannelLayoutTagAmbisonic_B_Format     uint32 = 107<<16 | 4
+	ChannelLayoutTagQuad                   uint32 = 108<<16 | 4
+	ChannelLayoutTagSurround_5_0           uint32 = 117<<16 | 5
+	ChannelLayoutTagSurround_5_1           uint32 = 121<<16 | 6
+	ChannelLayoutTagSurround_7_1           uint32 = 126<<16 | 8
+)
+
+// Channel bitmap bits from CoreAudioBaseTypes.h (kAudioChannelBit_*), used
+// with ChannelLayoutTagUseChannelBitmap.
+const (
+	ChannelBitmapLeft           uint32 = 1 << 0
+	ChannelBitmapRight          uint32 = 1 << 1
+	ChannelBitmapCenter         uint32 = 1 << 2
+	ChannelBitmapLFE            uint32 = 1 << 3
+	ChannelBitmapLeftSurround   uint32 = 1 << 4
+	ChannelBitmapRightSurround  uint32 = 1 << 5
+	ChannelBitmapLeftCenter     uint32 = 1 << 6
+	ChannelBitmapRightCenter    uint32 = 1 << 7
+	ChannelBitmapCenterSurround uint32 = 1 << 8
+)
+
+// Channel labels from CoreAudioBaseTypes.h (kAudioChannelLabel_*), used in
+// ChannelDescription.ChannelLabel.
+const (
+	ChannelLabelUnknown        uint32 = 0xFFFFFFFF
+	ChannelLabelUnused         uint32 = 0
+	ChannelLabelLeft           uint32 = 1
+	ChannelLabelRight          uint32 = 2
+	ChannelLabelCenter         uint32 = 3
+	ChannelLabelLFE            uint32 = 4
+	ChannelLabelLeftSurround   uint32 = 5
+	ChannelLabelRightSurround  uint32 = 6
+	ChannelLabelLeftCenter     uint32 = 7
+	ChannelLabelRightCenter    uint32 = 8
+	ChannelLabelCenterSurround uint32 = 9
+)
+
+// ChannelLayoutTagChannelCount returns the number of channels encoded in the
+// low 16 bits of tag.
+func ChannelLayoutTagChannelCount(tag uint32) int {
+	return int(tag & 0xffff)
+}
+
+var channelLayoutTagNames = map[uint32]string{
+	ChannelLayoutTagUseChannelDescriptions: "Use Channel Descriptions",
+	ChannelLayoutTagUseChannelBitmap:       "Use Channel Bitmap",
+	ChannelLayoutTagMono:                   "Mono",
+	ChannelLayoutTagStereo:                 "Stereo",
+	ChannelLayoutTagBinaural:               "Binaural",
+	ChannelLayoutTagAmbisonic_B_Format:     "Ambisonic B-Format",
+	ChannelLayoutTagQuad:                   "Quadraphonic",
+	ChannelLayoutTagSurround_5_0:           "5.0",
+	ChannelLayoutTagSurround_5_1:           "5.1",
+	ChannelLayoutTagSurround_7_1:           "7.1",
+}
+
+// ChannelLayoutTagString returns the name of the layout tag.
+func (c *ChannelLayout) ChannelLayoutTagString() string {
+	if name, ok := channelLayoutTagNames[c.ChannelLayoutTag]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChannelLayoutTag(0x%08X)", c.ChannelLayoutTag)
+}
+
+var channelLabelNames = map[uint32]string{
+	ChannelLabelUnknown:        "Unknown",
+	ChannelLabelUnused:         "Unused",
+	ChannelLabelLeft:           "Left",
+	ChannelLabelRight:          "Right",
+	ChannelLabelCenter:         "Center",
+	ChannelLabelLFE:            "LFE",
+	ChannelLabelLeftSurround:   "Left Surround",
+	ChannelLabelRightSurround:  "Right Surround",
+	ChannelLabelLeftCenter:     "Left Center",
+	ChannelLabelRightCenter:    "Right Center",
+	ChannelLabelCenterSurround: "Center Surround",
+}
+
+// LabelString returns the name of the channel label.
+func (c *ChannelDescription) LabelString() string {
+	if name, ok := channelLabelNames[c.ChannelLabel]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChannelLabel(0x%08X)", c.ChannelLabel)
+}
+
+// CoordinateX returns the first coordinate, the X axis or azimuth.
+func (c *ChannelDescription) CoordinateX() float32 {
+	return c.Coordinates[0]
+}
+
+// CoordinateY returns the second coordinate, the Y axis or elevation.
+func (c *ChannelDescription) CoordinateY() float32 {
+	return c.Coordinates[1]
+}
+
+// CoordinateZ returns the third coordinate, the Z axis or distance.
+func (c *ChannelDescription) CoordinateZ() float32 {
+	return c.Coordinates[2]
+}
+
+// NewMonoChannelLayout returns a single channel layout.
+func NewMonoChannelLayout() ChannelLayout {
+	return NewSurroundChannelLayout(ChannelLayoutTagMono)
+}
+
+// NewStereoChannelLayout returns a left/right layout.
+func NewStereoChannelLayout() ChannelLayout {
+	return NewSurroundChannelLayout(ChannelLayoutTagStereo)
+}
+
+// NewSurroundChannelLayout returns a layout described only by tag.
+func NewSurroundChannelLayout(tag uint32) ChannelLayout {
+	return ChannelLayout{ChannelLayoutTag: tag}
+}
+
+// UsesLayoutTag reports whether the layout is described by a named layout
+// tag rather than a bitmap or channel descriptions.
+func (c *ChannelLayout) UsesLayoutTag() bool {
+	return c.ChannelLayoutTag != ChannelLayoutTagUseChannelDescriptions &&
+		c.ChannelLayoutTag != ChannelLayoutTagUseChannelBitmap
+}
+
+// UsesBitmap reports whether the layout is described by its channel bitmap,
+// either because the tag says so or because no tag is set and the bitmap is
+// not empty.
+func (c *ChannelLayout) UsesBitmap() bool {
+	return c.ChannelLayoutTag == ChannelLayoutTagUseChannelBitmap ||
+		c.ChannelLayoutTag == ChannelLayoutTagUseChannelDescriptions && c.ChannelBitmap != 0
+}
+
+// HasExplicitDescriptions reports whether the layout is described by its
+// channel descriptions.
+func (c *ChannelLayout) HasExplicitDescriptions() bool {
+	return !c.UsesLayoutTag() && !c.UsesBitmap() && c.NumberChannelDescriptions != 0
+}
+
+// ChannelCount returns the number of channels in the layout, taken from the
+// tag, the bitmap or the channel descriptions, whichever describes it.
+func (c *ChannelLayout) ChannelCount() int {
+	switch {
+	case c.UsesLayoutTag():
+		return ChannelLayoutTagChannelCount(c.ChannelLayoutTag)
+	case c.UsesBitmap():
+		return bits.OnesCount32(c.ChannelBitmap)
+	default:
+		return int(c.NumberChannelDescriptions)
+	}
+}
+
+// Validate checks that the layout is described by exactly one of its tag,
+// bitmap or channel descriptions.
+func (c *ChannelLayout) Validate() error {
+	if c.NumberChannelDescriptions != 0 && c.UsesLayoutTag() {
+		return ErrLayoutTagWithDescriptions
+	}
+	if c.NumberChannelDescriptions != 0 && c.UsesBitmap() {
+		return ErrBitmapWithDescriptions
+	}
+	if uint32(len(c.Channels)) != c.NumberChannelDescriptions {
+		return ErrChannelDescriptionCountMismatch
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package caf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChannelLayoutConstructors(t *testing.T) {
+	tests := []struct {
+		layout ChannelLayout
+		tag    uint32
+	}{
+		{NewMonoChannelLayout(), 0x640001},
+		{NewStereoChannelLayout(), 0x650002},
+		{NewSurroundChannelLayout(121<<16 | 6), 0x790006},
+	}
+	for _, test := range tests {
+		if test.layout.ChannelLayoutTag != test.tag {
+			t.Errorf("expected tag %#x, got %#x", test.tag, test.layout.ChannelLayoutTag)
+		}
+		if test.layout.ChannelBitmap != 0 || test.layout.NumberChannelDescriptions != 0 || len(test.layout.Channels) != 0 {
+			t.Errorf("expected tag-only layout, got %+v", test.layout)
+		}
+		buf := &bytes.Buffer{}
+		if err := test.layout.encode(buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != 12 {
+			t.Errorf("expected 12 encoded bytes, got %d", buf.Len())
+		}
+	}
+}
+
+func TestChannelLayoutValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout ChannelLayout
+		err    error
+	}{
+		{"tag", NewStereoChannelLayout(), nil},
+		{"bitmap", ChannelLayout{ChannelBitmap: 3}, nil},
+		{"descriptions", ChannelLayout{NumberChannelDescriptions: 1, Channels: []ChannelDescription{{ChannelLabel: ChannelLabelLeft}}}, nil},
+		{"tag with descriptions", ChannelLayout{ChannelLayoutTag: ChannelLayoutTagMono, NumberChannelDescriptions: 1, Channels: []ChannelDescription{{}}}, ErrLayoutTagWithDescriptions},
+		{"bitmap with descriptions", ChannelLayout{ChannelBitmap: 3, NumberChannelDescriptions: 1, Channels: []ChannelDescription{{}}}, ErrBitmapWithDescriptions},
+		{"bitmap tag with descriptions", ChannelLayout{ChannelLayoutTag: ChannelLayoutTagUseChannelBitmap, NumberChannelDescriptions: 1, Channels: []ChannelDescription{{}}}, ErrBitmapWithDescriptions},
+		{"description count mismatch", ChannelLayout{NumberChannelDescriptions: 2, Channels: []ChannelDescription{{}}}, ErrChannelDescriptionCountMismatch},
+	}
+	for _, test := range tests {
+		if err := test.layout.Validate(); err != test.err {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestChannelLayoutTagChannelCount(t *testing.T) {
+	tests := map[uint32]int{
+		ChannelLayoutTagUseChannelDescriptions: 0,
+		ChannelLayoutTagUseChannelBitmap:       0,
+		ChannelLayoutTagMono:                   1,
+		ChannelLayoutTagStereo:                 2,
+		ChannelLayoutTagQuad:                   4,
+		ChannelLayoutTagSurround_5_0:           5,
+		ChannelLayoutTagSurround_5_1:           6,
+		ChannelLayoutTagSurround_7_1:           8,
+	}
+	for tag, expected := range tests {
+		if count := ChannelLayoutTagChannelCount(tag); count != expected {
+			t.Errorf("%#x: expected %d channels, got %d", tag, expected, count)
+		}
+	}
+}
+
+func TestChannelLayoutTagString(t *testing.T) {
+	tests := map[uint32]string{
+		ChannelLayoutTagUseChannelDescriptions: "Use Channel Descriptions",
+		ChannelLayoutTagUseChannelBitmap:       "Use Channel Bitmap",
+		ChannelLayoutTagStereo:                 "Stereo",
+		ChannelLayoutTagBinaural:               "Binaural",
+		ChannelLayoutTagAmbisonic_B_Format:     "Ambisonic B-Format",
+		ChannelLayoutTagSurround_5_1:           "5.1",
+		0x00930006:                             "ChannelLayoutTag(0x00930006)",
+	}
+	for tag, expected := range tests {
+		cl := NewSurroundChannelLayout(tag)
+		if name := cl.ChannelLayoutTagString(); name != expected {
+			t.Errorf("0x%08X: expected %q, got %q", tag, expected, name)
+		}
+	}
+}
+
+func TestChannelDescriptionAccessors(t *testing.T) {
+	d := ChannelDescription{ChannelLabel: ChannelLabelLeftSurround, Coordinates: [3]float32{-110, 0, 1}}
+	if d.CoordinateX() != -110 || d.CoordinateY() != 0 || d.CoordinateZ() != 1 {
+		t.Errorf("unexpected coordinates %v %v %v", d.CoordinateX(), d.CoordinateY(), d.CoordinateZ())
+	}
+	tests := map[uint32]string{
+		ChannelLabelLeft:           "Left",
+		ChannelLabelRight:          "Right",
+		ChannelLabelCenter:         "Center",
+		ChannelLabelLFE:            "LFE",
+		ChannelLabelLeftSurround:   "Left Surround",
+		ChannelLabelRightSurround:  "Right Surround",
+		ChannelLabelLeftCenter:     "Left Center",
+		ChannelLabelRightCenter:    "Right Center",
+		ChannelLabelCenterSurround: "Center Surround",
+		ChannelLabelUnused:         "Unused",
+		ChannelLabelUnknown:        "Unknown",
+		100:                        "ChannelLabel(0x00000064)",
+	}
+	for label, expected := range tests {
+		d := ChannelDescription{ChannelLabel: label}
+		if name := d.LabelString(); name != expected {
+			t.Errorf("%d: expected %q, got %q", label, expected, name)
+		}
+	}
+}
+
+func TestChannelLayoutChannelCount(t *testing.T) {
+	tests := []struct {
+		layout   ChannelLayout
+		expected int
+	}{
+		{NewStereoChannelLayout(), 2},
+		{NewSurroundChannelLayout(ChannelLayoutTagSurround_5_1), 6},
+		{ChannelLayout{ChannelLayoutTag: ChannelLayoutTagUseChannelBitmap, ChannelBitmap: ChannelBitmapLeft | ChannelBitmapRight | ChannelBitmapLFE}, 3},
+		{ChannelLayout{ChannelLayoutTag: ChannelLayoutTagUseChannelBitmap}, 0},
+		{ChannelLayout{ChannelLayoutTag: ChannelLayoutTagStereo, ChannelBitmap: ChannelBitmapLeft}, 2},
+		{ChannelLayout{NumberChannelDescriptions: 1, Channels: []ChannelDescription{{ChannelLabel: ChannelLabelCenter}}}, 1},
+	}
+	for i, test := range tests {
+		if n := test.layout.ChannelCount(); n != test.expected {
+			t.Errorf("%d: expected %d channels, got %d", i, test.expected, n)
+		}
+	}
+}
+
+func TestChannelLayoutPredicates(t *testing.T) {
+	tests := []struct {
+		name         string
+		layout       ChannelLayout
+		tag          bool
+		bitmap       bool
+		descriptions bool
+	}{
+		{"tag", NewStereoChannelLayout(), true, false, false},
+		{"bitmap tag", ChannelLayout{ChannelLayoutTag: ChannelLayoutTagUseChannelBitmap, ChannelBitmap: ChannelBitmapLeft}, false, true, false},
+		{"bitmap without tag", ChannelLayout{ChannelBitmap: ChannelBitmapLeft | ChannelBitmapRight}, false, true, false},
+		{"descriptions", ChannelLayout{NumberChannelDescriptions: 1, Channels: []ChannelDescription{{ChannelLabel: ChannelLabelCenter}}}, false, false, true},
+		{"empty", ChannelLayout{}, false, false, false},
+	}
+	for _, test := range tests {
+		if test.layout.UsesLayoutTag() != test.tag {
+			t.Errorf("%s: expected UsesLayoutTag %v", test.name, test.tag)
+		}
+		if test.layout.UsesBitmap() != test.bitmap {
+			t.Errorf("%s: expected UsesBitmap %v", test.name, test.bitmap)
+		}
+		if test.layout.HasExplicitDescriptions() != test.descriptions {
+			t.Errorf("%s: expected HasExplicitDescriptions %v", test.name, test.descriptions)
+		}
+	}
+}
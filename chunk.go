@@ -0,0 +1,241 @@
+package caf
+
+import (
+	"fmt"
+	"io"
+)
+
+// AsAudioFormat returns the contents of a desc chunk.
+func (c *Chunk) AsAudioFormat() (*AudioFormat, bool) {
+	if c.Header.ChunkType != ChunkTypeAudioDescription {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*AudioFormat)
+	return cc, ok
+}
+
+// AsChannelLayout returns the contents of a chan chunk.
+func (c *Chunk) AsChannelLayout() (*ChannelLayout, bool) {
+	if c.Header.ChunkType != ChunkTypeChannelLayout {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*ChannelLayout)
+	return cc, ok
+}
+
+// AsCAFStrings returns the contents of an info chunk.
+func (c *Chunk) AsCAFStrings() (*CAFStringsChunk, bool) {
+	if c.Header.ChunkType != ChunkTypeInformation {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*CAFStringsChunk)
+	return cc, ok
+}
+
+// AsData returns the contents of a data chunk.
+func (c *Chunk) AsData() (*Data, bool) {
+	if c.Header.ChunkType != ChunkTypeAudioData {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*Data)
+	return cc, ok
+}
+
+// AsPacketTable returns the contents of a pakt chunk.
+func (c *Chunk) AsPacketTable() (*PacketTable, bool) {
+	if c.Header.ChunkType != ChunkTypePacketTable {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*PacketTable)
+	return cc, ok
+}
+
+// AsMidi returns the contents of a midi chunk.
+func (c *Chunk) AsMidi() (Midi, bool) {
+	if c.Header.ChunkType != ChunkTypeMidi {
+		return nil, false
+	}
+	cc, ok := c.Contents.(Midi)
+	return cc, ok
+}
+
+// AsFreeSpace returns the contents of a free chunk.
+func (c *Chunk) AsFreeSpace() (*FreeSpaceChunk, bool) {
+	if c.Header.ChunkType != ChunkTypeFreeSpace {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*FreeSpaceChunk)
+	return cc, ok
+}
+
+// AsMarkers returns the contents of a mark chunk.
+func (c *Chunk) AsMarkers() (*MarkerChunk, bool) {
+	if c.Header.ChunkType != ChunkTypeMarkers {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*MarkerChunk)
+	return cc, ok
+}
+
+// AsRegions returns the contents of a regn chunk.
+func (c *Chunk) AsRegions() (*RegionChunk, bool) {
+	if c.Header.ChunkType != ChunkTypeRegion {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*RegionChunk)
+	return cc, ok
+}
+
+// AsPeak returns the contents of a peak chunk.
+func (c *Chunk) AsPeak() (*PeakChunk, bool) {
+	if c.Header.ChunkType != ChunkTypePeak {
+		return nil, false
+	}
+	cc, ok := c.Contents.(*PeakChunk)
+	return cc, ok
+}
+
+// AsUnknown returns the contents of a chunk whose type the decoder does not
+// understand.
+func (c *Chunk) AsUnknown() (*UnknownContents, bool) {
+	cc, ok := c.Contents.(*UnknownContents)
+	return cc, ok
+}
+
+// IsKnownType reports whether the decoder understands the chunk's type.
+func (c *Chunk) IsKnownType() bool {
+	return isKnownChunkType(c.Header.ChunkType)
+}
+
+// TypeString returns a human readable name for the chunk's type.
+func (c *Chunk) TypeString() string {
+	switch c.Header.ChunkType {
+	case ChunkTypeAudioDescription:
+		return "Audio Description"
+	case ChunkTypeAudioData:
+		return "Audio Data"
+	case ChunkTypePacketTable:
+		return "Packet Table"
+	case ChunkTypeChannelLayout:
+		return "Channel Layout"
+	case ChunkTypeInformation:
+		return "Information"
+	case ChunkTypeMidi:
+		return "MIDI"
+	case ChunkTypeFreeSpace:
+		return "Free Space"
+	case ChunkTypeMarkers:
+		return "Markers"
+	case ChunkTypeRegion:
+		return "Regions"
+	case ChunkTypePeak:
+		return "Peak Amplitude"
+	default:
+		return fmt.Sprintf("Unknown (%v)", c.Header.ChunkType)
+	}
+}
+
+// IsValidEncodedSize reports whether Header.ChunkSize matches the encoded
+// size of the contents. A data chunk of size -1 is always valid.
+func (c *Chunk) IsValidEncodedSize() bool {
+	if c.Header.ChunkType == ChunkTypeAudioData && c.Header.ChunkSize == -1 {
+		return true
+	}
+	size, err := c.ContentEncodedSize()
+	return err == nil && size == c.Header.ChunkSize
+}
+
+// UpdateSize sets Header.ChunkSize to the encoded size of the contents,
+// leaving a data chunk of size -1 streaming. Call it after changing contents
+// in place, for example with Data.Append, so that Validate accepts the chunk.
+func (c *Chunk) UpdateSize() error {
+	if c.Header.ChunkType == ChunkTypeAudioData && c.Header.ChunkSize == -1 {
+		return nil
+	}
+	size, err := c.ContentEncodedSize()
+	if err != nil {
+		return err
+	}
+	c.Header.ChunkSize = size
+	return nil
+}
+
+// newChunk returns a chunk of the given type holding contents, with the
+// header size set to the encoded size of contents.
+func newChunk(chunkType FourByteString, contents interface{}) Chunk {
+	c := Chunk{Header: ChunkHeader{ChunkType: chunkType}, Contents: contents}
+	size, err := c.ContentEncodedSize()
+	if err != nil {
+		logger.Debugf("Could not size %v chunk: %v", chunkType, err)
+	}
+	c.Header.ChunkSize = size
+	return c
+}
+
+// ContentEncodedSize returns the number of bytes the chunk's contents occupy
+// when encoded, which is the ChunkSize Encode writes in its header.
+func (c *Chunk) ContentEncodedSize() (int64, error) {
+	switch cc := c.Contents.(type) {
+	case *AudioFormat:
+		return audioFormatSize, nil
+	case *ChannelLayout:
+		return channelLayoutHeaderSize + channelDescriptionSize*int64(cc.NumberChannelDescriptions), nil
+	case *CAFStringsChunk:
+		size := int64(4)
+		for _, info := range cc.Strings {
+			size += int64(len(info.Key)) + 1 + int64(len(info.Value)) + 1
+		}
+		return size, nil
+	case *PacketTable:
+		return int64(cc.EncodedSize()), nil
+	case *Data:
+		return 4 + cc.Size(), nil
+	case Midi:
+		return int64(len(cc)), nil
+	case *FreeSpaceChunk:
+		if cc.Size < 0 {
+			return 0, ErrInvalidChunkSize
+		}
+		return cc.Size, nil
+	case *PeakChunk:
+		return 4 + channelPeakDataSize*int64(len(cc.Peaks)), nil
+	case *UnknownContents:
+		return int64(len(cc.Data)), nil
+	case *MarkerChunk, *RegionChunk:
+		cw := &countingWriter{w: io.Discard}
+		err := c.encodeContents(cw)
+		return cw.n, err
+	default:
+		return 0, fmt.Errorf("%w: %T in %v chunk", ErrUnknownContents, c.Contents, c.Header.ChunkType)
+	}
+}
+
+// NewAudioDescriptionChunk returns a desc chunk holding af.
+func NewAudioDescriptionChunk(af AudioFormat) Chunk {
+	return newChunk(ChunkTypeAudioDescription, &af)
+}
+
+// NewChannelLayoutChunk returns a chan chunk holding cl.
+func NewChannelLayoutChunk(cl ChannelLayout) Chunk {
+	return newChunk(ChunkTypeChannelLayout, &cl)
+}
+
+// NewAudioDataChunk returns a data chunk holding data.
+func NewAudioDataChunk(data []byte, editCount uint32) Chunk {
+	return newChunk(ChunkTypeAudioData, &Data{EditCount: editCount, Data: data})
+}
+
+// NewPacketTableChunk returns a pakt chunk holding pt.
+func NewPacketTableChunk(pt PacketTable) Chunk {
+	return newChunk(ChunkTypePacketTable, &pt)
+}
+
+// NewInformationChunk returns an info chunk holding entries, ordered by key.
+func NewInformationChunk(entries map[string]string) Chunk {
+	return newChunk(ChunkTypeInformation, NewCAFStringsChunkFromMap(entries))
+}
+
+// NewMidiChunk returns a midi chunk holding data.
+func NewMidiChunk(data []byte) Chunk {
+	return newChunk(ChunkTypeMidi, Midi(data))
+}
@@ -0,0 +1,244 @@
+package caf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func allChunkTypesFile(t *testing.T) *File {
+	f := testFile()
+	layout := NewStereoChannelLayout()
+	f.Chunks = append(f.Chunks,
+		Chunk{Header: ChunkHeader{ChunkType: ChunkTypeChannelLayout}, Contents: &layout},
+		Chunk{Header: ChunkHeader{ChunkType: ChunkTypeInformation}, Contents: NewCAFStringsChunkFromMap(map[string]string{"title": "test"})},
+		Chunk{Header: ChunkHeader{ChunkType: ChunkTypePacketTable}, Contents: &PacketTable{Header: PacketTableHeader{NumberPackets: 2}, Entry: []uint64{4, 4}}},
+		Chunk{Header: ChunkHeader{ChunkType: stringToChunkType("zzzz")}, Contents: &UnknownContents{Data: []byte{1, 2, 3}}},
+		freeChunk(t, 8),
+		Chunk{Header: ChunkHeader{ChunkType: ChunkTypeMarkers}, Contents: &MarkerChunk{NumberMarkers: 1, Markers: []Marker{{ID: 1}}}},
+		Chunk{Header: ChunkHeader{ChunkType: ChunkTypeRegion}, Contents: &RegionChunk{NumberRegions: 1, Regions: []Region{{RegionID: 1, NumberMarkers: 1, Markers: []Marker{{ID: 2}}}}}},
+		Chunk{Header: ChunkHeader{ChunkType: ChunkTypePeak}, Contents: &PeakChunk{Peaks: []ChannelPeakData{{Value: 0.5, FrameOffset: 1}, {Value: 0.25, FrameOffset: 0}}}},
+	)
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestChunkAccessors(t *testing.T) {
+	f := allChunkTypesFile(t)
+	for i := range f.Chunks {
+		c := &f.Chunks[i]
+		matches := 0
+		if cc, ok := c.AsAudioFormat(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsChannelLayout(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsCAFStrings(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsData(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsPacketTable(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsMidi(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsFreeSpace(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsMarkers(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsRegions(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsPeak(); ok && cc != nil {
+			matches++
+		}
+		if cc, ok := c.AsUnknown(); ok && cc != nil {
+			matches++
+		}
+		if matches != 1 {
+			t.Errorf("%v chunk: expected exactly one accessor to match, got %d", c.Header.ChunkType, matches)
+		}
+	}
+
+	mismatched := Chunk{Header: ChunkHeader{ChunkType: ChunkTypeAudioData}, Contents: &AudioFormat{}}
+	if _, ok := mismatched.AsAudioFormat(); ok {
+		t.Error("expected audio format accessor to check the chunk type")
+	}
+	if _, ok := mismatched.AsData(); ok {
+		t.Error("expected data accessor to check the contents type")
+	}
+}
+
+func TestChunkTypeString(t *testing.T) {
+	tests := map[FourByteString]string{
+		ChunkTypeAudioDescription: "Audio Description",
+		ChunkTypeAudioData:        "Audio Data",
+		ChunkTypePacketTable:      "Packet Table",
+		ChunkTypeChannelLayout:    "Channel Layout",
+		ChunkTypeInformation:      "Information",
+		ChunkTypeMidi:             "MIDI",
+		ChunkTypeRegion:           "Regions",
+		ChunkTypePeak:             "Peak Amplitude",
+		stringToChunkType("uuid"): "Unknown (uuid)",
+	}
+	for chunkType, expected := range tests {
+		c := Chunk{Header: ChunkHeader{ChunkType: chunkType}}
+		if s := c.TypeString(); s != expected {
+			t.Errorf("expected %q, got %q", expected, s)
+		}
+	}
+}
+
+func TestUnknownChunks(t *testing.T) {
+	f := allChunkTypesFile(t)
+	unknown := f.UnknownChunks()
+	if len(unknown) != 1 || unknown[0].Header.ChunkType != stringToChunkType("zzzz") {
+		t.Errorf("expected only the zzzz chunk, got %v", unknown)
+	}
+	for _, c := range f.Chunks {
+		if c.IsKnownType() == (c.Header.ChunkType == stringToChunkType("zzzz")) {
+			t.Errorf("%v: unexpected IsKnownType %v", c.Header.ChunkType, c.IsKnownType())
+		}
+	}
+}
+
+func TestUnknownChunkOriginalType(t *testing.T) {
+	f := allChunkTypesFile(t)
+	unknown, ok := f.Chunks[7].AsUnknown()
+	if !ok || unknown.OriginalChunkType != stringToChunkType("zzzz") {
+		t.Fatalf("expected original type zzzz, got %+v", unknown)
+	}
+	f.Chunks[7].Header.ChunkType = stringToChunkType("yyyy")
+	decoded := roundTrip(t, f)
+	unknown, _ = decoded.Chunks[7].AsUnknown()
+	if decoded.Chunks[7].Header.ChunkType != stringToChunkType("yyyy") || unknown.OriginalChunkType != stringToChunkType("yyyy") {
+		t.Errorf("expected retyped yyyy chunk, got %v with original type %v", decoded.Chunks[7].Header.ChunkType, unknown.OriginalChunkType)
+	}
+	if !bytes.Equal(unknown.Data, []byte{1, 2, 3}) {
+		t.Errorf("expected data to survive retyping, got %v", unknown.Data)
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	f := allChunkTypesFile(t)
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	err := (&File{}).DecodeStrict(bytes.NewReader(buf.Bytes()))
+	if !errors.Is(err, ErrUnknownChunkType) {
+		t.Fatalf("expected ErrUnknownChunkType, got %v", err)
+	}
+	var chunkErr *ChunkDecodeError
+	if !errors.As(err, &chunkErr) || chunkErr.ChunkType != stringToChunkType("zzzz") {
+		t.Errorf("expected chunk decode error for zzzz, got %v", err)
+	}
+
+	f.Chunks = f.FilterChunks(func(c Chunk) bool { return c.IsKnownType() })
+	buf.Reset()
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&File{}).DecodeStrict(buf); err != nil {
+		t.Errorf("expected known chunks to decode, got %v", err)
+	}
+}
+
+func TestChunkFactories(t *testing.T) {
+	tests := []struct {
+		chunk     Chunk
+		chunkType FourByteString
+		size      int64
+	}{
+		{NewAudioDescriptionChunk(AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM}), ChunkTypeAudioDescription, 32},
+		{NewChannelLayoutChunk(NewStereoChannelLayout()), ChunkTypeChannelLayout, 12},
+		{NewAudioDataChunk([]byte{1, 2, 3}, 7), ChunkTypeAudioData, 7},
+		{NewPacketTableChunk(PacketTable{Header: PacketTableHeader{NumberPackets: 2}, Entry: []uint64{1, 200}}), ChunkTypePacketTable, 27},
+		{NewInformationChunk(map[string]string{"title": "a"}), ChunkTypeInformation, 12},
+		{NewMidiChunk([]byte{1, 2}), ChunkTypeMidi, 2},
+	}
+	for _, test := range tests {
+		if test.chunk.Header.ChunkType != test.chunkType || test.chunk.Header.ChunkSize != test.size {
+			t.Errorf("%v: expected %v chunk of %d bytes, got %+v", test.chunkType, test.chunkType, test.size, test.chunk.Header)
+		}
+		buf := &bytes.Buffer{}
+		if err := test.chunk.encode(buf, false); err != nil {
+			t.Fatal(err)
+		}
+		var decoded Chunk
+		if err := decoded.decode(bufio.NewReader(buf)); err != nil {
+			t.Fatalf("%v: %v", test.chunkType, err)
+		}
+		if !decoded.Equal(&test.chunk) {
+			t.Errorf("%v: expected %+v, got %+v", test.chunkType, test.chunk, decoded)
+		}
+	}
+}
+
+func TestContentEncodedSize(t *testing.T) {
+	f := allChunkTypesFile(t)
+	for _, c := range f.Chunks {
+		size, err := c.ContentEncodedSize()
+		if err != nil {
+			t.Fatalf("%v: %v", c.Header.ChunkType, err)
+		}
+		buf := &bytes.Buffer{}
+		if err := c.encodeContents(buf); err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(buf.Len()) {
+			t.Errorf("%v: expected size %d, got %d", c.Header.ChunkType, buf.Len(), size)
+		}
+	}
+	c := Chunk{Header: ChunkHeader{ChunkType: ChunkTypeAudioData}, Contents: "not data"}
+	if _, err := c.ContentEncodedSize(); !errors.Is(err, ErrUnknownContents) {
+		t.Errorf("expected ErrUnknownContents, got %v", err)
+	}
+}
+
+func TestFileEncodedSize(t *testing.T) {
+	f := allChunkTypesFile(t)
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if size := f.EncodedSize(); size != int64(buf.Len()) {
+		t.Errorf("expected size %d, got %d", buf.Len(), size)
+	}
+}
+
+func TestIsValidEncodedSize(t *testing.T) {
+	for _, c := range allChunkTypesFile(t).Chunks {
+		if !c.IsValidEncodedSize() {
+			t.Errorf("%v: expected decoded size %d to be valid", c.Header.ChunkType, c.Header.ChunkSize)
+		}
+		c.Header.ChunkSize++
+		if c.IsValidEncodedSize() {
+			t.Errorf("%v: expected size %d to be invalid", c.Header.ChunkType, c.Header.ChunkSize)
+		}
+	}
+	streaming := NewAudioDataChunk([]byte{1, 2}, 0)
+	streaming.Header.ChunkSize = -1
+	if !streaming.IsValidEncodedSize() {
+		t.Error("expected a streaming data chunk to be valid")
+	}
+	free := freeChunk(t, 4)
+	free.Header.ChunkSize = -1
+	if free.IsValidEncodedSize() {
+		t.Error("expected only data chunks to allow size -1")
+	}
+}
@@ -0,0 +1,87 @@
+package caf
+
+// Clone returns a deep copy of the file that shares no memory with the
+// original.
+func (cf *File) Clone() *File {
+	clone := &File{FileHeader: cf.FileHeader}
+	if cf.Chunks != nil {
+		clone.Chunks = make([]Chunk, len(cf.Chunks))
+	}
+	for i, c := range cf.Chunks {
+		c.Contents = cloneContents(c.Contents)
+		clone.Chunks[i] = c
+	}
+	return clone
+}
+
+// Clone returns a copy of the audio format.
+func (c *AudioFormat) Clone() *AudioFormat {
+	af := *c
+	return &af
+}
+
+// Clone returns a copy of the layout that shares no memory with the
+// original.
+func (c *ChannelLayout) Clone() *ChannelLayout {
+	cl := *c
+	cl.Channels = append([]ChannelDescription(nil), c.Channels...)
+	return &cl
+}
+
+// Clone returns a copy of the packet table that shares no memory with the
+// original.
+func (c *PacketTable) Clone() *PacketTable {
+	pt := *c
+	pt.Entry = append([]uint64(nil), c.Entry...)
+	return &pt
+}
+
+// Clone returns a copy of the audio data that shares no memory with the
+// original.
+func (c *Data) Clone() *Data {
+	data := *c
+	data.Data = append([]byte(nil), c.Data...)
+	return &data
+}
+
+func cloneContents(contents interface{}) interface{} {
+	switch cc := contents.(type) {
+	case *AudioFormat:
+		return cc.Clone()
+	case *ChannelLayout:
+		return cc.Clone()
+	case *CAFStringsChunk:
+		strings := *cc
+		strings.Strings = append([]Information(nil), cc.Strings...)
+		return &strings
+	case *Data:
+		return cc.Clone()
+	case *PacketTable:
+		return cc.Clone()
+	case Midi:
+		return append(Midi(nil), cc...)
+	case *FreeSpaceChunk:
+		free := *cc
+		return &free
+	case *MarkerChunk:
+		markers := *cc
+		markers.Markers = append([]Marker(nil), cc.Markers...)
+		return &markers
+	case *RegionChunk:
+		regions := *cc
+		regions.Regions = make([]Region, len(cc.Regions))
+		for i, region := range cc.Regions {
+			region.Markers = append([]Marker(nil), region.Markers...)
+			regions.Regions[i] = region
+		}
+		return &regions
+	case *PeakChunk:
+		peak := *cc
+		peak.Peaks = append([]ChannelPeakData(nil), cc.Peaks...)
+		return &peak
+	case *UnknownContents:
+		return &UnknownContents{OriginalChunkType: cc.OriginalChunkType, Data: append([]byte(nil), cc.Data...)}
+	default:
+		return contents
+	}
+}
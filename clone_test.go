@@ -0,0 +1,71 @@
+package caf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileClone(t *testing.T) {
+	f := allChunkTypesFile(t)
+	clone := f.Clone()
+	if !reflect.DeepEqual(f, clone) {
+		t.Fatal("expected clone to equal original")
+	}
+
+	af, _ := clone.Chunks[0].AsAudioFormat()
+	af.SampleRate = 8000
+	data, _ := clone.Chunks[3].AsData()
+	data.Data[0] = 0xff
+	layout, _ := clone.Chunks[4].AsChannelLayout()
+	layout.ChannelLayoutTag = 0
+	clone.Chunks[5].Contents.(*CAFStringsChunk).Strings[0].Value = "changed"
+	clone.Chunks[6].Contents.(*PacketTable).Entry[0] = 99
+	clone.Chunks[1].Contents.(Midi)[0] = 0xff
+	clone.Chunks[7].Contents.(*UnknownContents).Data[0] = 0xff
+	clone.Chunks[9].Contents.(*MarkerChunk).Markers[0].ID = 2
+	clone.Chunks[10].Contents.(*RegionChunk).Regions[0].Markers[0].ID = 3
+	clone.Chunks[11].Contents.(*PeakChunk).Peaks[0].Value = 1
+
+	if !reflect.DeepEqual(f, allChunkTypesFile(t)) {
+		t.Error("mutating the clone changed the original")
+	}
+}
+
+func TestContentsClone(t *testing.T) {
+	af := &AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM}
+	afClone := af.Clone()
+	afClone.SampleRate = 8000
+	if af.SampleRate != 44100 {
+		t.Error("mutating the audio format clone changed the original")
+	}
+
+	layout := NewStereoChannelLayout()
+	layout.Channels = []ChannelDescription{{ChannelLabel: ChannelLabelLeft}, {ChannelLabel: ChannelLabelRight}}
+	layoutClone := layout.Clone()
+	layoutClone.Channels[0].ChannelLabel = ChannelLabelCenter
+	if layout.Channels[0].ChannelLabel != ChannelLabelLeft {
+		t.Error("mutating the channel layout clone changed the original")
+	}
+
+	pt := &PacketTable{Header: PacketTableHeader{NumberPackets: 2}, Entry: []uint64{1, 2}}
+	ptClone := pt.Clone()
+	ptClone.Entry[0] = 99
+	if pt.Entry[0] != 1 || !reflect.DeepEqual(ptClone.Header, pt.Header) {
+		t.Error("mutating the packet table clone changed the original")
+	}
+
+	data := &Data{EditCount: 1, Data: []byte{1, 2, 3}}
+	dataClone := data.Clone()
+	dataClone.Data[0] = 0xff
+	if data.Data[0] != 1 || dataClone.EditCount != 1 {
+		t.Error("mutating the data clone changed the original")
+	}
+}
+
+func TestFileCloneKeepsOffsets(t *testing.T) {
+	f := testFile()
+	f.Chunks[1].Offset = 52
+	if clone := f.Clone(); clone.Chunks[1].Offset != 52 {
+		t.Errorf("expected offset 52, got %d", clone.Chunks[1].Offset)
+	}
+}
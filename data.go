@@ -0,0 +1,33 @@
+package caf
+
+import (
+	"bytes"
+	"io"
+)
+
+// Bytes returns a copy of the audio data.
+func (c *Data) Bytes() []byte {
+	return append([]byte(nil), c.Data...)
+}
+
+// Reader returns a reader over the audio data starting at its first byte.
+func (c *Data) Reader() io.Reader {
+	return bytes.NewReader(c.Data)
+}
+
+// Size returns the number of bytes of audio data, excluding the edit count.
+func (c *Data) Size() int64 {
+	return int64(len(c.Data))
+}
+
+// Append adds b to the end of the audio data. Call UpdateSize on the chunk
+// holding c afterwards to keep its header size in step.
+func (c *Data) Append(b []byte) {
+	c.Data = append(c.Data, b...)
+}
+
+// SetEditCount sets the edit count. The spec starts it at 0 for a new file
+// and expects it to be incremented whenever the audio data is changed.
+func (c *Data) SetEditCount(n uint32) {
+	c.EditCount = n
+}
@@ -0,0 +1,40 @@
+package caf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestDataAccessors(t *testing.T) {
+	d := &Data{EditCount: 3, Data: []byte{1, 2, 3, 4}}
+	if d.Size() != 4 {
+		t.Errorf("expected size 4, got %d", d.Size())
+	}
+	b := d.Bytes()
+	b[0] = 0xff
+	if d.Data[0] != 1 {
+		t.Error("expected Bytes to return a copy")
+	}
+	read, err := io.ReadAll(d.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, d.Data) {
+		t.Errorf("expected %v, got %v", d.Data, read)
+	}
+}
+
+func TestDataMutators(t *testing.T) {
+	d := &Data{}
+	d.Append([]byte{1, 2})
+	d.Append(nil)
+	d.Append([]byte{3})
+	if !bytes.Equal(d.Data, []byte{1, 2, 3}) || d.Size() != 3 {
+		t.Errorf("unexpected data %v", d.Data)
+	}
+	d.SetEditCount(2)
+	if d.EditCount != 2 {
+		t.Errorf("expected edit count 2, got %d", d.EditCount)
+	}
+}
@@ -0,0 +1,147 @@
+package caf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ChunkDiffKind says how a chunk differs between two files.
+type ChunkDiffKind int
+
+const (
+	ChunkAdded ChunkDiffKind = iota
+	ChunkRemoved
+	ChunkChanged
+)
+
+func (k ChunkDiffKind) String() string {
+	switch k {
+	case ChunkAdded:
+		return "added"
+	case ChunkRemoved:
+		return "removed"
+	case ChunkChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("ChunkDiffKind(%d)", int(k))
+}
+
+// ChunkDiff describes one chunk that differs between two files. Index is the
+// position of the chunk in the new file for added chunks and in the old file
+// otherwise. Changes lists the differing fields of changed chunks.
+type ChunkDiff struct {
+	Index    int
+	Kind     ChunkDiffKind
+	OldChunk *Chunk
+	NewChunk *Chunk
+	Changes  []string
+}
+
+func (d ChunkDiff) String() string {
+	switch d.Kind {
+	case ChunkAdded:
+		return fmt.Sprintf("chunk %d (%v) added", d.Index, d.NewChunk.Header.ChunkType)
+	case ChunkRemoved:
+		return fmt.Sprintf("chunk %d (%v) removed", d.Index, d.OldChunk.Header.ChunkType)
+	}
+	return fmt.Sprintf("chunk %d (%v) changed: %v", d.Index, d.OldChunk.Header.ChunkType, d.Changes)
+}
+
+// Diff compares the chunks of the file with those of other. Chunks are paired
+// by type and by how many chunks of that type came before them, so the nth
+// midi chunk of one file is compared with the nth midi chunk of the other.
+// The file headers are not compared.
+func (cf *File) Diff(other *File) ([]ChunkDiff, error) {
+	if cf == nil || other == nil {
+		return nil, ErrNilFile
+	}
+	type key struct {
+		chunkType FourByteString
+		n         int
+	}
+	keys := func(chunks []Chunk) []key {
+		seen := make(map[FourByteString]int)
+		ks := make([]key, len(chunks))
+		for i, c := range chunks {
+			ks[i] = key{c.Header.ChunkType, seen[c.Header.ChunkType]}
+			seen[c.Header.ChunkType]++
+		}
+		return ks
+	}
+	newIndex := make(map[key]int)
+	for i, k := range keys(other.Chunks) {
+		newIndex[k] = i
+	}
+
+	var diffs []ChunkDiff
+	matched := make(map[int]bool)
+	for i, k := range keys(cf.Chunks) {
+		oldChunk := &cf.Chunks[i]
+		j, ok := newIndex[k]
+		if !ok {
+			diffs = append(diffs, ChunkDiff{Index: i, Kind: ChunkRemoved, OldChunk: oldChunk})
+			continue
+		}
+		matched[j] = true
+		newChunk := &other.Chunks[j]
+		if changes := diffChunk(oldChunk, newChunk); len(changes) > 0 {
+			diffs = append(diffs, ChunkDiff{Index: i, Kind: ChunkChanged, OldChunk: oldChunk, NewChunk: newChunk, Changes: changes})
+		}
+	}
+	for j := range other.Chunks {
+		if !matched[j] {
+			diffs = append(diffs, ChunkDiff{Index: j, Kind: ChunkAdded, NewChunk: &other.Chunks[j]})
+		}
+	}
+	return diffs, nil
+}
+
+func diffChunk(a, b *Chunk) []string {
+	var changes []string
+	diffField := func(name string, x, y interface{}) {
+		if x != y {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, x, y))
+		}
+	}
+	diffField("ChunkSize", a.Header.ChunkSize, b.Header.ChunkSize)
+	switch aa := a.Contents.(type) {
+	case *AudioFormat:
+		if bb, ok := b.Contents.(*AudioFormat); ok {
+			diffField("SampleRate", aa.SampleRate, bb.SampleRate)
+			diffField("FormatID", aa.FormatID, bb.FormatID)
+			diffField("FormatFlags", aa.FormatFlags, bb.FormatFlags)
+			diffField("BytesPerPacket", aa.BytesPerPacket, bb.BytesPerPacket)
+			diffField("FramesPerPacket", aa.FramesPerPacket, bb.FramesPerPacket)
+			diffField("ChannelsPerPacket", aa.ChannelsPerPacket, bb.ChannelsPerPacket)
+			diffField("BitsPerChannel", aa.BitsPerChannel, bb.BitsPerChannel)
+			return changes
+		}
+	case *Data:
+		if bb, ok := b.Contents.(*Data); ok {
+			diffField("EditCount", aa.EditCount, bb.EditCount)
+			if len(aa.Data) != len(bb.Data) {
+				changes = append(changes, fmt.Sprintf("audio data: %d bytes -> %d bytes", len(aa.Data), len(bb.Data)))
+			} else if !bytes.Equal(aa.Data, bb.Data) {
+				changes = append(changes, "audio data contents differ")
+			}
+			return changes
+		}
+	case *PacketTable:
+		if bb, ok := b.Contents.(*PacketTable); ok {
+			diffField("NumberPackets", aa.Header.NumberPackets, bb.Header.NumberPackets)
+			diffField("NumberValidFrames", aa.Header.NumberValidFrames, bb.Header.NumberValidFrames)
+			diffField("PrimingFrames", aa.Header.PrimingFrames, bb.Header.PrimingFrames)
+			diffField("RemainderFrames", aa.Header.RemainderFrames, bb.Header.RemainderFrames)
+			if len(aa.Entry) != len(bb.Entry) {
+				changes = append(changes, fmt.Sprintf("entries: %d -> %d", len(aa.Entry), len(bb.Entry)))
+			} else if !contentsEqual(aa, bb) {
+				changes = append(changes, "entries differ")
+			}
+			return changes
+		}
+	}
+	if !contentsEqual(a.Contents, b.Contents) {
+		changes = append(changes, "contents differ")
+	}
+	return changes
+}
@@ -0,0 +1,50 @@
+package caf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	if diffs, err := testFile().Diff(testFile()); err != nil || len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v (%v)", diffs, err)
+	}
+
+	old, changed := testFile(), testFile()
+	af, _ := changed.Chunks[0].AsAudioFormat()
+	af.SampleRate = 48000
+	data, _ := changed.Chunks[3].AsData()
+	data.Data = append(data.Data, 9, 9)
+	changed.Chunks[3].Header.ChunkSize += 2
+	changed.Chunks = append(changed.Chunks[:2], changed.Chunks[3], freeChunk(t, 4))
+	diffs, err := old.Diff(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		index   int
+		kind    ChunkDiffKind
+		changes []string
+	}{
+		{0, ChunkChanged, []string{"SampleRate: 44100 -> 48000"}},
+		{2, ChunkRemoved, nil},
+		{3, ChunkChanged, []string{"ChunkSize: 12 -> 14", "audio data: 8 bytes -> 10 bytes"}},
+		{3, ChunkAdded, nil},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d differences, got %v", len(expected), diffs)
+	}
+	for i, e := range expected {
+		d := diffs[i]
+		if d.Index != e.index || d.Kind != e.kind || !reflect.DeepEqual(d.Changes, e.changes) {
+			t.Errorf("%d: expected %d %v %v, got %v", i, e.index, e.kind, e.changes, d)
+		}
+	}
+	if diffs[1].OldChunk != &old.Chunks[2] || diffs[3].NewChunk != &changed.Chunks[3] {
+		t.Error("expected diffs to point at the compared chunks")
+	}
+
+	if _, err := old.Diff(nil); err != ErrNilFile {
+		t.Errorf("expected ErrNilFile, got %v", err)
+	}
+}
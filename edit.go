@@ -0,0 +1,139 @@
+package caf
+
+// TrimPackets returns a copy of the file holding only packets startPacket up
+// to but not including endPacket. The original file is left unchanged.
+func (cf *File) TrimPackets(startPacket, endPacket int64) (*File, error) {
+	af, err := cf.audioFormat()
+	if err != nil {
+		return nil, err
+	}
+	trimmed := cf.Clone()
+	dataChunk, ok := trimmed.ChunkByType(ChunkTypeAudioData)
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	data, ok := dataChunk.AsData()
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	var pt *PacketTable
+	if paktChunk, ok := trimmed.ChunkByType(ChunkTypePacketTable); ok {
+		pt, _ = paktChunk.AsPacketTable()
+	}
+	if pt == nil && af.BytesPerPacket == 0 {
+		return nil, ErrMissingPacketTable
+	}
+
+	var numPackets int64
+	if pt != nil {
+		numPackets = pt.Header.NumberPackets
+		if int64(len(pt.Entry)) != numPackets {
+			return nil, ErrPacketCountMismatch
+		}
+	} else {
+		numPackets = int64(len(data.Data)) / int64(af.BytesPerPacket)
+	}
+	if startPacket < 0 || startPacket > endPacket || endPacket > numPackets {
+		return nil, ErrPacketIndexOutOfRange
+	}
+
+	var startByte, endByte int64
+	if af.BytesPerPacket != 0 {
+		startByte = startPacket * int64(af.BytesPerPacket)
+		endByte = endPacket * int64(af.BytesPerPacket)
+	} else {
+		for i, size := range pt.Entry[:endPacket] {
+			if int64(i) < startPacket {
+				startByte += int64(size)
+			}
+			endByte += int64(size)
+		}
+	}
+	if endByte > int64(len(data.Data)) {
+		return nil, ErrPacketCountMismatch
+	}
+	data.Data = data.Data[startByte:endByte]
+	if dataChunk.Header.ChunkSize != -1 {
+		dataChunk.Header.ChunkSize = 4 + int64(len(data.Data))
+	}
+
+	if pt != nil {
+		if af.FramesPerPacket == 0 {
+			return nil, ErrInvalidFramesPerPacket
+		}
+		header := pt.Header
+		if err := pt.Trim(startPacket, endPacket, int64(af.FramesPerPacket)); err != nil {
+			return nil, err
+		}
+		if startPacket == 0 {
+			pt.Header.PrimingFrames = header.PrimingFrames
+		}
+		if endPacket == numPackets {
+			pt.Header.RemainderFrames = header.RemainderFrames
+		}
+		pt.Header.NumberValidFrames -= int64(pt.Header.PrimingFrames) + int64(pt.Header.RemainderFrames)
+		if pt.Header.NumberValidFrames < 0 {
+			pt.Header.NumberValidFrames = 0
+		}
+		paktChunk, _ := trimmed.ChunkByType(ChunkTypePacketTable)
+		paktChunk.Header.ChunkSize = int64(pt.EncodedSize())
+	}
+	return trimmed, nil
+}
+
+// ConcatWith returns a copy of the file with the audio of other appended to
+// its own. The formats must be equivalent, see AudioFormat.EquivalentTo. The
+// result keeps the priming frames of the file and the remainder frames of
+// other; neither input is modified.
+func (cf *File) ConcatWith(other *File) (*File, error) {
+	af, err := cf.audioFormat()
+	if err != nil {
+		return nil, err
+	}
+	otherAF, err := other.audioFormat()
+	if err != nil {
+		return nil, err
+	}
+	if !af.EquivalentTo(*otherAF) {
+		return nil, ErrFormatMismatch
+	}
+	otherData, err := other.FirstAudioData()
+	if err != nil {
+		return nil, err
+	}
+	joined := cf.Clone()
+	dataChunk, ok := joined.ChunkByType(ChunkTypeAudioData)
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	data, ok := dataChunk.AsData()
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+
+	var pt, otherPT *PacketTable
+	paktChunk, hasPakt := joined.ChunkByType(ChunkTypePacketTable)
+	if hasPakt {
+		pt, _ = paktChunk.AsPacketTable()
+	}
+	if otherPaktChunk, ok := other.ChunkByType(ChunkTypePacketTable); ok {
+		otherPT, _ = otherPaktChunk.AsPacketTable()
+	}
+	if (pt == nil && af.BytesPerPacket == 0) || (pt != nil && otherPT == nil) {
+		return nil, ErrMissingPacketTable
+	}
+
+	data.Append(otherData.Data)
+	if dataChunk.Header.ChunkSize != -1 {
+		dataChunk.Header.ChunkSize = 4 + int64(len(data.Data))
+	}
+	if pt != nil {
+		for _, size := range otherPT.Entry {
+			pt.AddEntry(size)
+		}
+		pt.Header.NumberValidFrames += otherPT.Header.NumberValidFrames
+		pt.Header.RemainderFrames = otherPT.Header.RemainderFrames
+		paktChunk.Header.ChunkSize = int64(pt.EncodedSize())
+	}
+	return joined, nil
+}
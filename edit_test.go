@@ -0,0 +1,142 @@
+package caf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func vbrTestFile() *File {
+	f := NewFile(AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2})
+	pt, _ := f.Chunks[1].AsPacketTable()
+	packets := [][]byte{{1}, {2, 2}, {3, 3, 3}, {4, 4, 4, 4}}
+	var data []byte
+	for _, p := range packets {
+		pt.AddEntry(uint64(len(p)))
+		data = append(data, p...)
+	}
+	pt.Header.PrimingFrames = 312
+	pt.Header.RemainderFrames = 100
+	pt.Header.NumberValidFrames = 4*960 - 312 - 100
+	f.Chunks[1].Header.ChunkSize = int64(pt.EncodedSize())
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypeAudioData, ChunkSize: 4 + 10}, Contents: &Data{Data: data}})
+	return f
+}
+
+func TestTrimPacketsVBR(t *testing.T) {
+	f := vbrTestFile()
+	trimmed, err := f.TrimPackets(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, vbrTestFile()) {
+		t.Error("trimming modified the original file")
+	}
+	trimmed = roundTrip(t, trimmed)
+	if err := trimmed.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := trimmed.Chunks[2].AsData()
+	if !bytes.Equal(data.Data, []byte{2, 2, 3, 3, 3}) {
+		t.Errorf("unexpected audio %v", data.Data)
+	}
+	pt, _ := trimmed.Chunks[1].AsPacketTable()
+	expected := PacketTableHeader{NumberPackets: 2, NumberValidFrames: 2 * 960}
+	if pt.Header != expected {
+		t.Errorf("expected header %+v, got %+v", expected, pt.Header)
+	}
+	if !reflect.DeepEqual(pt.Entry, []uint64{2, 3}) {
+		t.Errorf("unexpected entries %v", pt.Entry)
+	}
+
+	trimmed, err = f.TrimPackets(0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(trimmed, f) {
+		t.Error("expected trimming every packet to keep the file unchanged")
+	}
+}
+
+func TestTrimPacketsCBR(t *testing.T) {
+	f := testFile()
+	trimmed, err := f.TrimPackets(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := trimmed.Chunks[3].AsData()
+	if len(data.Data) != 4 || trimmed.Chunks[3].Header.ChunkSize != 8 {
+		t.Errorf("expected one packet of audio, got %d bytes", len(data.Data))
+	}
+	if _, ok := trimmed.ChunkByType(ChunkTypePacketTable); ok {
+		t.Error("expected no packet table")
+	}
+}
+
+func TestTrimPacketsErrors(t *testing.T) {
+	for _, r := range [][2]int64{{-1, 1}, {2, 1}, {0, 5}} {
+		if _, err := vbrTestFile().TrimPackets(r[0], r[1]); err != ErrPacketIndexOutOfRange {
+			t.Errorf("%v: expected ErrPacketIndexOutOfRange, got %v", r, err)
+		}
+	}
+	f := vbrTestFile()
+	f.Chunks = append(f.Chunks[:1], f.Chunks[2:]...)
+	if _, err := f.TrimPackets(0, 1); err != ErrMissingPacketTable {
+		t.Errorf("expected ErrMissingPacketTable, got %v", err)
+	}
+}
+
+func TestConcatWith(t *testing.T) {
+	a, b := vbrTestFile(), vbrTestFile()
+	pt, _ := b.Chunks[1].AsPacketTable()
+	pt.Header.RemainderFrames = 200
+	pt.Header.NumberValidFrames = 4*960 - 312 - 200
+	joined, err := a.ConcatWith(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, vbrTestFile()) {
+		t.Error("concatenating modified the original file")
+	}
+	joined = roundTrip(t, joined)
+	if err := joined.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	pt, _ = joined.Chunks[1].AsPacketTable()
+	expected := PacketTableHeader{NumberPackets: 8, NumberValidFrames: 2*4*960 - 2*312 - 100 - 200, PrimingFrames: 312, RemainderFrames: 200}
+	if pt.Header != expected {
+		t.Errorf("expected header %+v, got %+v", expected, pt.Header)
+	}
+	data, _ := joined.Chunks[2].AsData()
+	if len(data.Data) != 20 || joined.Chunks[2].Header.ChunkSize != 24 {
+		t.Errorf("expected 20 bytes of audio, got %d", len(data.Data))
+	}
+
+	joined, err = testFile().ConcatWith(testFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = joined.Chunks[3].AsData()
+	if len(data.Data) != 16 {
+		t.Errorf("expected 16 bytes of audio, got %d", len(data.Data))
+	}
+}
+
+func TestConcatWithErrors(t *testing.T) {
+	if _, err := testFile().ConcatWith(vbrTestFile()); err != ErrFormatMismatch {
+		t.Errorf("expected ErrFormatMismatch, got %v", err)
+	}
+	noData := vbrTestFile()
+	noData.Chunks = noData.Chunks[:2]
+	if _, err := vbrTestFile().ConcatWith(noData); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+	noPakt := vbrTestFile()
+	noPakt.Chunks = append(noPakt.Chunks[:1], noPakt.Chunks[2:]...)
+	if _, err := vbrTestFile().ConcatWith(noPakt); err != ErrMissingPacketTable {
+		t.Errorf("expected ErrMissingPacketTable, got %v", err)
+	}
+	if _, err := (&File{}).ConcatWith(vbrTestFile()); err != ErrMissingAudioDescription {
+		t.Errorf("expected ErrMissingAudioDescription, got %v", err)
+	}
+}
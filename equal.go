@@ -0,0 +1,125 @@
+package caf
+
+import (
+	"bytes"
+)
+
+// Equal reports whether both files have the same header and the same chunks
+// in the same order with equal contents.
+func (cf *File) Equal(other *File) bool {
+	if cf == nil || other == nil {
+		return cf == other
+	}
+	if cf.FileHeader != other.FileHeader || len(cf.Chunks) != len(other.Chunks) {
+		return false
+	}
+	for i := range cf.Chunks {
+		if !cf.Chunks[i].Equal(&other.Chunks[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether both chunks have the same header and equal contents.
+func (c *Chunk) Equal(other *Chunk) bool {
+	return c.Header == other.Header && contentsEqual(c.Contents, other.Contents)
+}
+
+func contentsEqual(a, b interface{}) bool {
+	switch aa := a.(type) {
+	case nil:
+		return b == nil
+	case *AudioFormat:
+		bb, ok := b.(*AudioFormat)
+		return ok && *aa == *bb
+	case *ChannelLayout:
+		bb, ok := b.(*ChannelLayout)
+		if !ok || aa.ChannelLayoutTag != bb.ChannelLayoutTag || aa.ChannelBitmap != bb.ChannelBitmap ||
+			aa.NumberChannelDescriptions != bb.NumberChannelDescriptions || len(aa.Channels) != len(bb.Channels) {
+			return false
+		}
+		for i := range aa.Channels {
+			if aa.Channels[i] != bb.Channels[i] {
+				return false
+			}
+		}
+		return true
+	case *CAFStringsChunk:
+		bb, ok := b.(*CAFStringsChunk)
+		if !ok || aa.NumEntries != bb.NumEntries || len(aa.Strings) != len(bb.Strings) {
+			return false
+		}
+		for i := range aa.Strings {
+			if aa.Strings[i] != bb.Strings[i] {
+				return false
+			}
+		}
+		return true
+	case *Data:
+		bb, ok := b.(*Data)
+		return ok && aa.EditCount == bb.EditCount && bytes.Equal(aa.Data, bb.Data)
+	case *PacketTable:
+		bb, ok := b.(*PacketTable)
+		if !ok || aa.Header != bb.Header || len(aa.Entry) != len(bb.Entry) {
+			return false
+		}
+		for i := range aa.Entry {
+			if aa.Entry[i] != bb.Entry[i] {
+				return false
+			}
+		}
+		return true
+	case Midi:
+		bb, ok := b.(Midi)
+		return ok && bytes.Equal(aa, bb)
+	case *FreeSpaceChunk:
+		bb, ok := b.(*FreeSpaceChunk)
+		return ok && *aa == *bb
+	case *MarkerChunk:
+		bb, ok := b.(*MarkerChunk)
+		if !ok || aa.SMPTETimeType != bb.SMPTETimeType || aa.NumberMarkers != bb.NumberMarkers || len(aa.Markers) != len(bb.Markers) {
+			return false
+		}
+		for i := range aa.Markers {
+			if aa.Markers[i] != bb.Markers[i] {
+				return false
+			}
+		}
+		return true
+	case *RegionChunk:
+		bb, ok := b.(*RegionChunk)
+		if !ok || aa.SMPTETimeType != bb.SMPTETimeType || aa.NumberRegions != bb.NumberRegions || len(aa.Regions) != len(bb.Regions) {
+			return false
+		}
+		for i := range aa.Regions {
+			ra, rb := &aa.Regions[i], &bb.Regions[i]
+			if ra.RegionID != rb.RegionID || ra.Flags != rb.Flags || ra.NumberMarkers != rb.NumberMarkers || len(ra.Markers) != len(rb.Markers) {
+				return false
+			}
+			for j := range ra.Markers {
+				if ra.Markers[j] != rb.Markers[j] {
+					return false
+				}
+			}
+		}
+		return true
+	case *PeakChunk:
+		bb, ok := b.(*PeakChunk)
+		if !ok || aa.EditCount != bb.EditCount || len(aa.Peaks) != len(bb.Peaks) {
+			return false
+		}
+		for i := range aa.Peaks {
+			if aa.Peaks[i] != bb.Peaks[i] {
+				return false
+			}
+		}
+		return true
+	case *UnknownContents:
+		// OriginalChunkType only records where the chunk came from.
+		bb, ok := b.(*UnknownContents)
+		return ok && bytes.Equal(aa.Data, bb.Data)
+	default:
+		return false
+	}
+}
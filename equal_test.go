@@ -0,0 +1,46 @@
+package caf
+
+import (
+	"testing"
+)
+
+func TestFileEqual(t *testing.T) {
+	f := allChunkTypesFile(t)
+	if !f.Equal(f.Clone()) {
+		t.Fatal("expected clone to be equal")
+	}
+	if !f.Equal(roundTrip(t, f)) {
+		t.Fatal("expected round trip to be equal")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(f *File)
+	}{
+		{"header", func(f *File) { f.FileHeader.FileFlags = 1 }},
+		{"chunk count", func(f *File) { f.Chunks = f.Chunks[1:] }},
+		{"chunk order", func(f *File) { f.Chunks[1], f.Chunks[2] = f.Chunks[2], f.Chunks[1] }},
+		{"chunk size", func(f *File) { f.Chunks[0].Header.ChunkSize = 1 }},
+		{"audio format", func(f *File) { f.Chunks[0].Contents.(*AudioFormat).SampleRate = 1 }},
+		{"midi", func(f *File) { f.Chunks[1].Contents.(Midi)[0] = 0xff }},
+		{"data", func(f *File) { f.Chunks[3].Contents.(*Data).Data[7] = 0xff }},
+		{"channel layout", func(f *File) { f.Chunks[4].Contents.(*ChannelLayout).ChannelBitmap = 1 }},
+		{"information", func(f *File) { f.Chunks[5].Contents.(*CAFStringsChunk).Set("title", "other") }},
+		{"packet table", func(f *File) { f.Chunks[6].Contents.(*PacketTable).Entry[1] = 5 }},
+		{"unknown", func(f *File) { f.Chunks[7].Contents.(*UnknownContents).Data = nil }},
+		{"free space", func(f *File) { f.Chunks[8].Contents.(*FreeSpaceChunk).Size = 1 }},
+		{"markers", func(f *File) { f.Chunks[9].Contents.(*MarkerChunk).Markers[0].ID = 2 }},
+		{"region", func(f *File) { f.Chunks[10].Contents.(*RegionChunk).Regions[0].Flags = RegionFlagLoopEnable }},
+		{"region markers", func(f *File) { f.Chunks[10].Contents.(*RegionChunk).Regions[0].Markers[0].ID = 3 }},
+		{"peak", func(f *File) { f.Chunks[11].Contents.(*PeakChunk).Peaks[1].FrameOffset = 2 }},
+		{"contents type", func(f *File) { f.Chunks[0].Contents = &Data{} }},
+		{"unexpected contents", func(f *File) { f.Chunks[0].Contents = "desc" }},
+	}
+	for _, test := range tests {
+		modified := f.Clone()
+		test.modify(modified)
+		if f.Equal(modified) || modified.Equal(f) {
+			t.Errorf("%s: expected files to differ", test.name)
+		}
+	}
+}
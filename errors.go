@@ -0,0 +1,109 @@
+package caf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrInvalidFileType                 = errors.New("invalid caff header")
+	ErrUnsupportedFileVersion          = errors.New("unsupported caff file version")
+	ErrInvalidChunkType                = errors.New("chunk type must be four bytes")
+	ErrUnknownChunkType                = errors.New("unknown chunk type")
+	ErrInvalidChunkSize                = errors.New("invalid chunk size")
+	ErrChunkTooLarge                   = errors.New("chunk larger than allowed")
+	ErrUnexpectedEOF                   = io.ErrUnexpectedEOF
+	ErrVLQOverflow                     = errors.New("variable length integer too long")
+	ErrMalformedPacketTable            = errors.New("malformed packet table")
+	ErrMissingAudioDescription         = errors.New("missing audio description chunk")
+	ErrMultipleAudioDescriptions       = errors.New("more than one audio description chunk")
+	ErrMultipleAudioData               = errors.New("more than one audio data chunk")
+	ErrMissingPacketTable              = errors.New("missing packet table chunk for variable bit rate format")
+	ErrPacketCountMismatch             = errors.New("packet table entry count does not match number of packets")
+	ErrChannelDescriptionCountMismatch = errors.New("channel description count does not match number of channel descriptions")
+	ErrWriterClosed                    = errors.New("audio writer closed")
+	ErrChunkIndexOutOfRange            = errors.New("chunk index out of range")
+	ErrChunkNotFound                   = errors.New("chunk not found")
+	ErrPacketIndexOutOfRange           = errors.New("packet index out of range")
+	ErrLayoutTagWithDescriptions       = errors.New("channel layout tag set together with channel descriptions")
+	ErrBitmapWithDescriptions          = errors.New("channel bitmap set together with channel descriptions")
+	ErrInvalidSampleRate               = errors.New("invalid sample rate")
+	ErrInvalidChannelCount             = errors.New("invalid channels per packet")
+	ErrInvalidBitsPerChannel           = errors.New("invalid bits per channel")
+	ErrInvalidBytesPerPacket           = errors.New("bytes per packet does not match sample size")
+	ErrInvalidFramesPerPacket          = errors.New("invalid frames per packet")
+	ErrUnknownDuration                 = errors.New("not enough information to determine duration")
+	ErrFormatMismatch                  = errors.New("audio formats do not match")
+	ErrNilFile                         = errors.New("nil file")
+	ErrUnsupportedFormat               = errors.New("unsupported audio format")
+	ErrUnknownContents                 = errors.New("unknown chunk contents")
+	ErrEmptyInformationKey             = errors.New("empty information key")
+	ErrEmbeddedNUL                     = errors.New("string contains NUL byte")
+	ErrInformationTooLong              = errors.New("information string too long")
+	ErrNoChunks                        = errors.New("file has no chunks")
+	ErrStreamingDataNotLast            = errors.New("streaming data chunk is not the last chunk")
+)
+
+// ChunkDecodeError reports a failure to decode the chunk whose header starts
+// at Offset bytes into the file.
+type ChunkDecodeError struct {
+	ChunkType FourByteString
+	Offset    int64
+	Err       error
+}
+
+func (e *ChunkDecodeError) Error() string {
+	return fmt.Sprintf("decoding %v chunk at offset %d: %v", e.ChunkType, e.Offset, e.Err)
+}
+
+func (e *ChunkDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// multiError reports several problems at once.
+type multiError []error
+
+func (e multiError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether any of the errors matches target.
+func (e multiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundTripError reports a file that changed when encoded and decoded again.
+// Offset is the first byte at which re-encoding the decoded file differs from
+// the original encoding, or -1 if they match.
+type RoundTripError struct {
+	HeaderChanged bool
+	Diffs         []ChunkDiff
+	Offset        int64
+}
+
+func (e *RoundTripError) Error() string {
+	var first string
+	switch {
+	case e.HeaderChanged:
+		first = "file header changed"
+	case len(e.Diffs) > 0:
+		first = e.Diffs[0].String()
+	default:
+		first = "files differ"
+	}
+	if e.Offset < 0 {
+		return "round trip mismatch: " + first
+	}
+	return fmt.Sprintf("round trip mismatch: %s, encodings differ at offset %d", first, e.Offset)
+}
@@ -0,0 +1,88 @@
+package caf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func encodedTestFile(t *testing.T) []byte {
+	outputBuffer := &bytes.Buffer{}
+	if err := testFile().Encode(outputBuffer); err != nil {
+		t.Fatal(err)
+	}
+	return outputBuffer.Bytes()
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(b []byte) []byte
+		err    error
+		chunk  FourByteString
+		offset int64
+	}{
+		{"empty", func(b []byte) []byte {
+			return nil
+		}, ErrUnexpectedEOF, FourByteString{}, -1},
+		{"invalid file type", func(b []byte) []byte {
+			copy(b, "RIFF")
+			return b
+		}, ErrInvalidFileType, FourByteString{}, -1},
+		{"unsupported version", func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[4:], 2)
+			return b
+		}, ErrUnsupportedFileVersion, FourByteString{}, -1},
+		{"negative chunk size", func(b []byte) []byte {
+			binary.BigEndian.PutUint64(b[fileHeaderSize+4:], uint64(0xffffffffffffff00))
+			return b
+		}, ErrInvalidChunkSize, ChunkTypeAudioDescription, fileHeaderSize},
+		{"truncated desc body", func(b []byte) []byte {
+			return b[:fileHeaderSize+chunkHeaderSize]
+		}, ErrUnexpectedEOF, ChunkTypeAudioDescription, fileHeaderSize},
+		{"truncated midi body", func(b []byte) []byte {
+			return b[:fileHeaderSize+2*chunkHeaderSize+32]
+		}, ErrUnexpectedEOF, ChunkTypeMidi, fileHeaderSize + chunkHeaderSize + 32},
+		{"truncated data body", func(b []byte) []byte {
+			return b[:len(b)-1]
+		}, ErrUnexpectedEOF, ChunkTypeAudioData, fileHeaderSize + 3*chunkHeaderSize + 32 + 2 + 1},
+		{"huge midi chunk size", func(b []byte) []byte {
+			binary.BigEndian.PutUint64(b[fileHeaderSize+chunkHeaderSize+32+4:], 1<<62)
+			return b
+		}, ErrUnexpectedEOF, ChunkTypeMidi, fileHeaderSize + chunkHeaderSize + 32},
+		{"malformed packet table", func(b []byte) []byte {
+			buf := bytes.NewBuffer(b)
+			header := ChunkHeader{ChunkType: ChunkTypePacketTable, ChunkSize: packetTableHeaderSize}
+			if err := binary.Write(buf, binary.BigEndian, header); err != nil {
+				t.Fatal(err)
+			}
+			if err := binary.Write(buf, binary.BigEndian, PacketTableHeader{NumberPackets: -1}); err != nil {
+				t.Fatal(err)
+			}
+			return buf.Bytes()
+		}, ErrMalformedPacketTable, ChunkTypePacketTable, int64(len(encodedTestFile(t)))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := (&File{}).Decode(bytes.NewReader(test.modify(encodedTestFile(t))))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+			var chunkErr *ChunkDecodeError
+			if test.offset < 0 {
+				if errors.As(err, &chunkErr) {
+					t.Errorf("unexpected chunk decode error %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &chunkErr) {
+				t.Fatalf("expected chunk decode error, got %v", err)
+			}
+			if chunkErr.ChunkType != test.chunk || chunkErr.Offset != test.offset {
+				t.Errorf("expected %v chunk at offset %d, got %v at %d",
+					test.chunk, test.offset, chunkErr.ChunkType, chunkErr.Offset)
+			}
+		})
+	}
+}
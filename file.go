@@ -0,0 +1,359 @@
+package caf
+
+import (
+	"sort"
+	"time"
+)
+
+// ChunkByType returns the first chunk of type t and whether one was found.
+func (cf *File) ChunkByType(t FourByteString) (*Chunk, bool) {
+	i := cf.IndexOfChunk(t)
+	if i < 0 {
+		return nil, false
+	}
+	return &cf.Chunks[i], true
+}
+
+// IndexOfChunk returns the index of the first chunk of type t, or -1 if
+// there is none.
+func (cf *File) IndexOfChunk(t FourByteString) int {
+	for i := range cf.Chunks {
+		if cf.Chunks[i].Header.ChunkType == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// LastIndexOfChunk returns the index of the last chunk of type t, or -1 if
+// there is none.
+func (cf *File) LastIndexOfChunk(t FourByteString) int {
+	for i := len(cf.Chunks) - 1; i >= 0; i-- {
+		if cf.Chunks[i].Header.ChunkType == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// ChunksOfType returns every chunk of type t in file order.
+func (cf *File) ChunksOfType(t FourByteString) []*Chunk {
+	var chunks []*Chunk
+	for i := range cf.Chunks {
+		if cf.Chunks[i].Header.ChunkType == t {
+			chunks = append(chunks, &cf.Chunks[i])
+		}
+	}
+	return chunks
+}
+
+// NumChunks returns the number of chunks in the file.
+func (cf *File) NumChunks() int {
+	return len(cf.Chunks)
+}
+
+// ChunkAt returns a copy of the chunk at index.
+func (cf *File) ChunkAt(index int) (Chunk, error) {
+	if index < 0 || index >= len(cf.Chunks) {
+		return Chunk{}, ErrChunkIndexOutOfRange
+	}
+	return cf.Chunks[index], nil
+}
+
+// FirstChunkOfType returns a copy of the first chunk of type t.
+func (cf *File) FirstChunkOfType(t FourByteString) (Chunk, error) {
+	c, ok := cf.ChunkByType(t)
+	if !ok {
+		return Chunk{}, ErrChunkNotFound
+	}
+	return *c, nil
+}
+
+// InsertChunkAt inserts c before the chunk at index. An index equal to the
+// number of chunks appends c.
+func (cf *File) InsertChunkAt(index int, c Chunk) error {
+	if index < 0 || index > len(cf.Chunks) {
+		return ErrChunkIndexOutOfRange
+	}
+	cf.Chunks = append(cf.Chunks, Chunk{})
+	copy(cf.Chunks[index+1:], cf.Chunks[index:])
+	cf.Chunks[index] = c
+	return nil
+}
+
+// RemoveChunkAt removes the chunk at index.
+func (cf *File) RemoveChunkAt(index int) error {
+	if index < 0 || index >= len(cf.Chunks) {
+		return ErrChunkIndexOutOfRange
+	}
+	cf.Chunks = append(cf.Chunks[:index], cf.Chunks[index+1:]...)
+	return nil
+}
+
+// RemoveChunk removes the first chunk of type t and reports whether there
+// was one.
+func (cf *File) RemoveChunk(t FourByteString) bool {
+	return cf.RemoveChunkAt(cf.IndexOfChunk(t)) == nil
+}
+
+// RemoveAllChunksOfType removes every chunk of type t, keeping the remaining
+// chunks in order, and returns the number removed.
+func (cf *File) RemoveAllChunksOfType(t FourByteString) int {
+	chunks := cf.Chunks[:0]
+	for _, c := range cf.Chunks {
+		if c.Header.ChunkType != t {
+			chunks = append(chunks, c)
+		}
+	}
+	removed := len(cf.Chunks) - len(chunks)
+	cf.Chunks = chunks
+	return removed
+}
+
+// SwapChunks exchanges the chunks at i and j.
+func (cf *File) SwapChunks(i, j int) error {
+	if i < 0 || i >= len(cf.Chunks) || j < 0 || j >= len(cf.Chunks) {
+		return ErrChunkIndexOutOfRange
+	}
+	cf.Chunks[i], cf.Chunks[j] = cf.Chunks[j], cf.Chunks[i]
+	return nil
+}
+
+// Duration returns the length of the encoded audio. It is computed from the
+// packet table's TotalFrames when present, so it includes priming and
+// remainder frames, and otherwise estimated from the size of the audio data
+// for constant bit rate formats.
+func (cf *File) Duration() (time.Duration, error) {
+	af, err := cf.audioFormat()
+	if err != nil {
+		return 0, err
+	}
+	if af.SampleRate <= 0 {
+		return 0, ErrInvalidSampleRate
+	}
+	var frames int64
+	if paktChunk, ok := cf.ChunkByType(ChunkTypePacketTable); ok {
+		pt, ok := paktChunk.AsPacketTable()
+		if !ok {
+			return 0, ErrUnknownDuration
+		}
+		frames = pt.TotalFrames()
+	} else if dataChunk, ok := cf.ChunkByType(ChunkTypeAudioData); ok && af.BytesPerPacket > 0 && af.FramesPerPacket > 0 {
+		data, ok := dataChunk.AsData()
+		if !ok {
+			return 0, ErrUnknownDuration
+		}
+		packets := int64(len(data.Data)) / int64(af.BytesPerPacket)
+		frames = packets * int64(af.FramesPerPacket)
+	} else {
+		return 0, ErrUnknownDuration
+	}
+	return time.Duration(float64(frames) / af.SampleRate * float64(time.Second)), nil
+}
+
+// FirstAudioData returns the contents of the first data chunk.
+func (cf *File) FirstAudioData() (*Data, error) {
+	dataChunk, ok := cf.ChunkByType(ChunkTypeAudioData)
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	data, ok := dataChunk.AsData()
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	return data, nil
+}
+
+// ExtractMIDI returns a copy of the data in the first midi chunk.
+func (cf *File) ExtractMIDI() ([]byte, error) {
+	midiChunk, ok := cf.ChunkByType(ChunkTypeMidi)
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	midi, ok := midiChunk.AsMidi()
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	return append([]byte(nil), midi...), nil
+}
+
+// SetMIDI replaces the first midi chunk with one holding data, or appends
+// one if the file has none.
+func (cf *File) SetMIDI(data []byte) {
+	cf.UpsertChunkOfType(ChunkTypeMidi, NewMidiChunk(data))
+}
+
+// TotalAudioBytes returns the number of bytes of audio across all data
+// chunks.
+func (cf *File) TotalAudioBytes() int64 {
+	var total int64
+	for _, dataChunk := range cf.ChunksOfType(ChunkTypeAudioData) {
+		if data, ok := dataChunk.AsData(); ok {
+			total += data.Size()
+		}
+	}
+	return total
+}
+
+// EncodedSize returns the number of bytes Encode writes for the file.
+func (cf *File) EncodedSize() int64 {
+	size := int64(fileHeaderSize)
+	for i := range cf.Chunks {
+		contentSize, err := cf.Chunks[i].ContentEncodedSize()
+		if err != nil {
+			logger.Debugf("Could not size chunk %d, using stored size: %v", i, err)
+			contentSize = cf.Chunks[i].Header.ChunkSize
+		}
+		size += chunkHeaderSize + contentSize
+	}
+	return size
+}
+
+func (cf *File) audioFormat() (*AudioFormat, error) {
+	descChunk, ok := cf.ChunkByType(ChunkTypeAudioDescription)
+	if !ok {
+		return nil, ErrMissingAudioDescription
+	}
+	af, ok := descChunk.AsAudioFormat()
+	if !ok {
+		return nil, ErrMissingAudioDescription
+	}
+	return af, nil
+}
+
+// SampleRate returns the sample rate from the audio description.
+func (cf *File) SampleRate() (float64, error) {
+	af, err := cf.audioFormat()
+	if err != nil {
+		return 0, err
+	}
+	return af.SampleRate, nil
+}
+
+// NumChannels returns the channel count from the audio description.
+func (cf *File) NumChannels() (uint32, error) {
+	af, err := cf.audioFormat()
+	if err != nil {
+		return 0, err
+	}
+	return af.ChannelsPerPacket, nil
+}
+
+// BitDepth returns the bits per channel from the audio description, which is
+// zero for compressed formats.
+func (cf *File) BitDepth() (uint32, error) {
+	af, err := cf.audioFormat()
+	if err != nil {
+		return 0, err
+	}
+	return af.BitsPerChannel, nil
+}
+
+// ContainsAudio reports whether the file has both an audio description and
+// audio data.
+func (cf *File) ContainsAudio() bool {
+	_, hasDesc := cf.ChunkByType(ChunkTypeAudioDescription)
+	_, hasData := cf.ChunkByType(ChunkTypeAudioData)
+	return hasDesc && hasData
+}
+
+// ReplaceChunkOfType replaces the first chunk of type t with replacement,
+// returning ErrChunkNotFound if there is none.
+func (cf *File) ReplaceChunkOfType(t FourByteString, replacement Chunk) error {
+	c, ok := cf.ChunkByType(t)
+	if !ok {
+		return ErrChunkNotFound
+	}
+	*c = replacement
+	return nil
+}
+
+// UpsertChunkOfType replaces the first chunk of type t with replacement, or
+// appends replacement if there is none. The replacement is stored with type
+// t whatever its header says.
+func (cf *File) UpsertChunkOfType(t FourByteString, replacement Chunk) {
+	if replacement.Header.ChunkType != t {
+		logger.Debugf("Upserting %v chunk as %v", replacement.Header.ChunkType, t)
+		replacement.Header.ChunkType = t
+	}
+	if err := cf.ReplaceChunkOfType(t, replacement); err == ErrChunkNotFound {
+		cf.Chunks = append(cf.Chunks, replacement)
+	}
+}
+
+func specOrderRank(t FourByteString) int {
+	switch t {
+	case ChunkTypeAudioDescription:
+		return 0
+	case ChunkTypeChannelLayout:
+		return 1
+	case ChunkTypeInformation:
+		return 2
+	case ChunkTypePacketTable:
+		return 4
+	case ChunkTypeAudioData:
+		return 5
+	default:
+		return 3
+	}
+}
+
+// SortBySpecOrder reorders the chunks so that desc comes first, followed by
+// chan, info, any other chunks in their original order, pakt, and finally
+// data.
+func (cf *File) SortBySpecOrder() {
+	sort.SliceStable(cf.Chunks, func(i, j int) bool {
+		return specOrderRank(cf.Chunks[i].Header.ChunkType) < specOrderRank(cf.Chunks[j].Header.ChunkType)
+	})
+}
+
+// NewFile returns a file holding only a header and an audio description.
+// Formats with variable sized packets also get an empty packet table, so the
+// result passes Validate.
+func NewFile(af AudioFormat) *File {
+	f := &File{
+		FileHeader: NewFileHeader(),
+		Chunks:     []Chunk{NewAudioDescriptionChunk(af)},
+	}
+	if af.BytesPerPacket == 0 {
+		f.Chunks = append(f.Chunks, NewPacketTableChunk(PacketTable{}))
+	}
+	return f
+}
+
+// NewFileWithLayout is like NewFile but also adds a channel layout directly
+// after the audio description.
+func NewFileWithLayout(af AudioFormat, cl ChannelLayout) *File {
+	f := NewFile(af)
+	f.InsertChunkAt(1, NewChannelLayoutChunk(cl))
+	return f
+}
+
+// ForEachChunk calls fn with each chunk in order until fn returns false. The
+// chunk pointer may be used to modify the chunk in place.
+func (cf *File) ForEachChunk(fn func(index int, c *Chunk) bool) {
+	for i := range cf.Chunks {
+		if !fn(i, &cf.Chunks[i]) {
+			return
+		}
+	}
+}
+
+// FilterChunks returns copies of the chunks for which fn returns true.
+func (cf *File) FilterChunks(fn func(Chunk) bool) []Chunk {
+	var chunks []Chunk
+	for _, c := range cf.Chunks {
+		if fn(c) {
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
+
+// UnknownChunks returns copies of the chunks whose types the decoder does not
+// understand.
+func (cf *File) UnknownChunks() []Chunk {
+	return cf.FilterChunks(func(c Chunk) bool {
+		return !c.IsKnownType()
+	})
+}
@@ -0,0 +1,451 @@
+package caf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testFile() *File {
+	return &File{
+		FileHeader: NewFileHeader(),
+		Chunks: []Chunk{
+			{Header: ChunkHeader{ChunkType: ChunkTypeAudioDescription, ChunkSize: 32}, Contents: &AudioFormat{
+				SampleRate:        44100,
+				FormatID:          stringToChunkType("lpcm"),
+				BytesPerPacket:    4,
+				FramesPerPacket:   1,
+				ChannelsPerPacket: 2,
+				BitsPerChannel:    16,
+			}},
+			{Header: ChunkHeader{ChunkType: ChunkTypeMidi, ChunkSize: 2}, Contents: Midi{1, 2}},
+			{Header: ChunkHeader{ChunkType: ChunkTypeMidi, ChunkSize: 1}, Contents: Midi{3}},
+			{Header: ChunkHeader{ChunkType: ChunkTypeAudioData, ChunkSize: 12}, Contents: &Data{Data: make([]byte, 8)}},
+		},
+	}
+}
+
+func TestChunkByType(t *testing.T) {
+	f := testFile()
+	c, ok := f.ChunkByType(ChunkTypeMidi)
+	if !ok {
+		t.Fatal("expected to find midi chunk")
+	}
+	if c != &f.Chunks[1] {
+		t.Error("expected first midi chunk")
+	}
+	if c, ok := f.ChunkByType(ChunkTypePacketTable); ok || c != nil {
+		t.Error("expected no packet table chunk")
+	}
+}
+
+func TestIndexOfChunk(t *testing.T) {
+	f := testFile()
+	tests := []struct {
+		chunkType FourByteString
+		first     int
+		last      int
+	}{
+		{ChunkTypeAudioDescription, 0, 0},
+		{ChunkTypeMidi, 1, 2},
+		{ChunkTypeAudioData, 3, 3},
+		{ChunkTypeInformation, -1, -1},
+	}
+	for _, test := range tests {
+		if i := f.IndexOfChunk(test.chunkType); i != test.first {
+			t.Errorf("%v: expected first index %d, got %d", test.chunkType, test.first, i)
+		}
+		if i := f.LastIndexOfChunk(test.chunkType); i != test.last {
+			t.Errorf("%v: expected last index %d, got %d", test.chunkType, test.last, i)
+		}
+	}
+}
+
+func TestChunksOfType(t *testing.T) {
+	f := testFile()
+	chunks := f.ChunksOfType(ChunkTypeMidi)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 midi chunks, got %d", len(chunks))
+	}
+	if chunks[0] != &f.Chunks[1] || chunks[1] != &f.Chunks[2] {
+		t.Error("midi chunks returned out of order")
+	}
+	if chunks := f.ChunksOfType(ChunkTypePacketTable); len(chunks) != 0 {
+		t.Errorf("expected no packet table chunks, got %d", len(chunks))
+	}
+}
+
+func TestChunkAt(t *testing.T) {
+	f := testFile()
+	if f.NumChunks() != 4 {
+		t.Errorf("expected 4 chunks, got %d", f.NumChunks())
+	}
+	if c, err := f.ChunkAt(3); err != nil || c.Header.ChunkType != ChunkTypeAudioData {
+		t.Errorf("expected data chunk, got %v (%v)", c.Header.ChunkType, err)
+	}
+	for _, index := range []int{-1, 4} {
+		if _, err := f.ChunkAt(index); err != ErrChunkIndexOutOfRange {
+			t.Errorf("%d: expected ErrChunkIndexOutOfRange, got %v", index, err)
+		}
+	}
+	if c, err := f.FirstChunkOfType(ChunkTypeMidi); err != nil || !c.Equal(&f.Chunks[1]) {
+		t.Errorf("expected first midi chunk, got %+v (%v)", c, err)
+	}
+	if _, err := f.FirstChunkOfType(ChunkTypePacketTable); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+}
+
+func TestInsertRemoveSwapChunks(t *testing.T) {
+	f := testFile()
+	if err := f.InsertChunkAt(0, freeChunk(t, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.InsertChunkAt(f.NumChunks(), freeChunk(t, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.InsertChunkAt(2, NewChannelLayoutChunk(NewStereoChannelLayout())); err != nil {
+		t.Fatal(err)
+	}
+	expected := []FourByteString{ChunkTypeFreeSpace, ChunkTypeAudioDescription, ChunkTypeChannelLayout, ChunkTypeMidi, ChunkTypeMidi, ChunkTypeAudioData, ChunkTypeFreeSpace}
+	if types := chunkTypes(f); !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+	for _, index := range []int{-1, f.NumChunks() + 1} {
+		if err := f.InsertChunkAt(index, freeChunk(t, 1)); err != ErrChunkIndexOutOfRange {
+			t.Errorf("%d: expected ErrChunkIndexOutOfRange, got %v", index, err)
+		}
+	}
+
+	if err := f.RemoveChunkAt(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.RemoveChunkAt(f.NumChunks() - 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SwapChunks(1, 4); err != nil {
+		t.Fatal(err)
+	}
+	expected = []FourByteString{ChunkTypeAudioDescription, ChunkTypeAudioData, ChunkTypeMidi, ChunkTypeMidi, ChunkTypeChannelLayout}
+	if types := chunkTypes(f); !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+	for _, index := range []int{-1, f.NumChunks()} {
+		if err := f.RemoveChunkAt(index); err != ErrChunkIndexOutOfRange {
+			t.Errorf("%d: expected ErrChunkIndexOutOfRange, got %v", index, err)
+		}
+		if err := f.SwapChunks(0, index); err != ErrChunkIndexOutOfRange {
+			t.Errorf("%d: expected ErrChunkIndexOutOfRange, got %v", index, err)
+		}
+	}
+}
+
+func TestRemoveChunksOfType(t *testing.T) {
+	f := testFile()
+	if !f.RemoveChunk(ChunkTypeMidi) {
+		t.Error("expected a midi chunk to be removed")
+	}
+	expected := []FourByteString{ChunkTypeAudioDescription, ChunkTypeMidi, ChunkTypeAudioData}
+	if types := chunkTypes(f); !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+	if midi, _ := f.Chunks[1].AsMidi(); !reflect.DeepEqual(midi, Midi{3}) {
+		t.Errorf("expected the second midi chunk to remain, got %v", midi)
+	}
+	if f.RemoveChunk(ChunkTypeInformation) {
+		t.Error("expected no info chunk to be removed")
+	}
+
+	f = testFile()
+	if n := f.RemoveAllChunksOfType(ChunkTypeMidi); n != 2 {
+		t.Errorf("expected 2 chunks removed, got %d", n)
+	}
+	expected = []FourByteString{ChunkTypeAudioDescription, ChunkTypeAudioData}
+	if types := chunkTypes(f); !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+	if n := f.RemoveAllChunksOfType(ChunkTypeMidi); n != 0 {
+		t.Errorf("expected no chunks removed, got %d", n)
+	}
+}
+
+func TestAudioData(t *testing.T) {
+	f := testFile()
+	data, err := f.FirstAudioData()
+	if err != nil || data != f.Chunks[3].Contents {
+		t.Errorf("expected first data chunk contents, got %v (%v)", data, err)
+	}
+	f.Chunks = append(f.Chunks, NewAudioDataChunk([]byte{1, 2, 3}, 0))
+	if total := f.TotalAudioBytes(); total != 11 {
+		t.Errorf("expected 11 bytes of audio, got %d", total)
+	}
+	f.Chunks = f.Chunks[:3]
+	if _, err := f.FirstAudioData(); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+	if total := f.TotalAudioBytes(); total != 0 {
+		t.Errorf("expected no audio, got %d bytes", total)
+	}
+}
+
+func TestMIDI(t *testing.T) {
+	f := testFile()
+	midi, err := f.ExtractMIDI()
+	if err != nil || !bytes.Equal(midi, []byte{1, 2}) {
+		t.Errorf("expected first midi chunk, got %v (%v)", midi, err)
+	}
+	midi[0] = 0xff
+	if c, _ := f.Chunks[1].AsMidi(); c[0] != 1 {
+		t.Error("expected ExtractMIDI to return a copy")
+	}
+
+	f.SetMIDI([]byte{4, 5, 6})
+	decoded := roundTrip(t, f)
+	if midi, _ := decoded.ExtractMIDI(); !bytes.Equal(midi, []byte{4, 5, 6}) {
+		t.Errorf("expected replaced midi data, got %v", midi)
+	}
+	if f.Chunks[1].Header.ChunkSize != 3 || len(decoded.ChunksOfType(ChunkTypeMidi)) != 2 {
+		t.Errorf("expected the first midi chunk to be replaced, got %+v", f.Chunks[1].Header)
+	}
+
+	f.RemoveAllChunksOfType(ChunkTypeMidi)
+	if _, err := f.ExtractMIDI(); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+	f.SetMIDI([]byte{7})
+	if midi, err := f.ExtractMIDI(); err != nil || !bytes.Equal(midi, []byte{7}) {
+		t.Errorf("expected appended midi chunk, got %v (%v)", midi, err)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	f := testFile()
+	f.Chunks[3].Contents = &Data{Data: make([]byte, 4*44100)}
+	if d, err := f.Duration(); err != nil {
+		t.Fatal(err)
+	} else if d != time.Second {
+		t.Errorf("expected 1s from data size, got %v", d)
+	}
+
+	f.Chunks = append(f.Chunks, Chunk{
+		Header:   ChunkHeader{ChunkType: ChunkTypePacketTable},
+		Contents: &PacketTable{Header: PacketTableHeader{NumberValidFrames: 22050}},
+	})
+	if d, err := f.Duration(); err != nil {
+		t.Fatal(err)
+	} else if d != 500*time.Millisecond {
+		t.Errorf("expected 500ms from packet table, got %v", d)
+	}
+
+	if d, err := vbrTestFile().Duration(); err != nil {
+		t.Fatal(err)
+	} else if d != 80*time.Millisecond {
+		t.Errorf("expected 80ms including priming and remainder, got %v", d)
+	}
+
+	f = testFile()
+	f.Chunks[0].Contents.(*AudioFormat).BytesPerPacket = 0
+	if _, err := f.Duration(); err != ErrUnknownDuration {
+		t.Errorf("expected ErrUnknownDuration, got %v", err)
+	}
+
+	f.Chunks = f.Chunks[1:]
+	if _, err := f.Duration(); err != ErrMissingAudioDescription {
+		t.Errorf("expected ErrMissingAudioDescription, got %v", err)
+	}
+}
+
+func TestAudioFormatShortcuts(t *testing.T) {
+	f := testFile()
+	if sampleRate, err := f.SampleRate(); err != nil || sampleRate != 44100 {
+		t.Errorf("expected sample rate 44100, got %v (%v)", sampleRate, err)
+	}
+	if channels, err := f.NumChannels(); err != nil || channels != 2 {
+		t.Errorf("expected 2 channels, got %v (%v)", channels, err)
+	}
+	if bitDepth, err := f.BitDepth(); err != nil || bitDepth != 16 {
+		t.Errorf("expected bit depth 16, got %v (%v)", bitDepth, err)
+	}
+	if !f.ContainsAudio() {
+		t.Error("expected file to contain audio")
+	}
+
+	f.Chunks = f.Chunks[1:]
+	if _, err := f.SampleRate(); err != ErrMissingAudioDescription {
+		t.Errorf("expected ErrMissingAudioDescription, got %v", err)
+	}
+	if _, err := f.NumChannels(); err != ErrMissingAudioDescription {
+		t.Errorf("expected ErrMissingAudioDescription, got %v", err)
+	}
+	if _, err := f.BitDepth(); err != ErrMissingAudioDescription {
+		t.Errorf("expected ErrMissingAudioDescription, got %v", err)
+	}
+	if f.ContainsAudio() {
+		t.Error("expected file without desc chunk to not contain audio")
+	}
+}
+
+func roundTrip(t *testing.T, f *File) *File {
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func chunkTypes(f *File) []FourByteString {
+	var types []FourByteString
+	for _, c := range f.Chunks {
+		types = append(types, c.Header.ChunkType)
+	}
+	return types
+}
+
+func TestReplaceChunkOfType(t *testing.T) {
+	f := testFile()
+	af := *f.Chunks[0].Contents.(*AudioFormat)
+	af.SampleRate = 48000
+	replacement := Chunk{Header: ChunkHeader{ChunkType: ChunkTypeAudioDescription}, Contents: &af}
+	if err := f.ReplaceChunkOfType(ChunkTypeAudioDescription, replacement); err != nil {
+		t.Fatal(err)
+	}
+	decoded := roundTrip(t, f)
+	if !reflect.DeepEqual(chunkTypes(decoded), chunkTypes(testFile())) {
+		t.Errorf("chunk order changed: %v", chunkTypes(decoded))
+	}
+	if sampleRate, _ := decoded.SampleRate(); sampleRate != 48000 {
+		t.Errorf("expected sample rate 48000, got %v", sampleRate)
+	}
+	if err := f.ReplaceChunkOfType(ChunkTypePacketTable, replacement); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+}
+
+func TestUpsertChunkOfType(t *testing.T) {
+	f := testFile()
+	info := Chunk{Header: ChunkHeader{ChunkType: ChunkTypeInformation}, Contents: NewCAFStringsChunkFromMap(map[string]string{"title": "first"})}
+	f.UpsertChunkOfType(ChunkTypeInformation, info)
+	decoded := roundTrip(t, f)
+	expected := append(chunkTypes(testFile()), ChunkTypeInformation)
+	if !reflect.DeepEqual(chunkTypes(decoded), expected) {
+		t.Errorf("expected chunks %v, got %v", expected, chunkTypes(decoded))
+	}
+
+	info = Chunk{Header: ChunkHeader{ChunkType: ChunkTypeInformation}, Contents: NewCAFStringsChunkFromMap(map[string]string{"title": "second"})}
+	decoded.UpsertChunkOfType(ChunkTypeInformation, info)
+	decoded = roundTrip(t, decoded)
+	if !reflect.DeepEqual(chunkTypes(decoded), expected) {
+		t.Errorf("expected chunks %v, got %v", expected, chunkTypes(decoded))
+	}
+	c, _ := decoded.ChunkByType(ChunkTypeInformation)
+	if title, _ := c.Contents.(*CAFStringsChunk).Get("title"); title != "second" {
+		t.Errorf("expected title second, got %q", title)
+	}
+
+	layout := NewChannelLayoutChunk(NewStereoChannelLayout())
+	layout.Header.ChunkType = ChunkTypeFreeSpace
+	decoded.UpsertChunkOfType(ChunkTypeChannelLayout, layout)
+	if _, ok := decoded.ChunkByType(ChunkTypeFreeSpace); ok {
+		t.Error("expected the mislabelled chunk not to be stored as free space")
+	}
+	if c, ok := decoded.ChunkByType(ChunkTypeChannelLayout); !ok || c.Contents != layout.Contents {
+		t.Error("expected the chunk to be stored as a channel layout")
+	}
+}
+
+func TestSortBySpecOrder(t *testing.T) {
+	unknown := stringToChunkType("zzzz")
+	layout := NewStereoChannelLayout()
+	f := &File{Chunks: []Chunk{
+		{Header: ChunkHeader{ChunkType: ChunkTypeAudioData}, Contents: &Data{}},
+		{Header: ChunkHeader{ChunkType: ChunkTypeMidi}, Contents: Midi{1}},
+		{Header: ChunkHeader{ChunkType: ChunkTypePacketTable}, Contents: &PacketTable{}},
+		{Header: ChunkHeader{ChunkType: ChunkTypeInformation}, Contents: &CAFStringsChunk{}},
+		{Header: ChunkHeader{ChunkType: unknown}, Contents: &UnknownContents{}},
+		{Header: ChunkHeader{ChunkType: ChunkTypeChannelLayout}, Contents: &layout},
+		{Header: ChunkHeader{ChunkType: ChunkTypeAudioDescription}, Contents: &AudioFormat{}},
+	}}
+	f.SortBySpecOrder()
+	expected := []FourByteString{
+		ChunkTypeAudioDescription,
+		ChunkTypeChannelLayout,
+		ChunkTypeInformation,
+		ChunkTypeMidi,
+		unknown,
+		ChunkTypePacketTable,
+		ChunkTypeAudioData,
+	}
+	if !reflect.DeepEqual(chunkTypes(f), expected) {
+		t.Errorf("expected %v, got %v", expected, chunkTypes(f))
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	pcm := AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM, BytesPerPacket: 4, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: 16}
+	opus := AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2}
+	tests := []struct {
+		name  string
+		file  *File
+		types []FourByteString
+	}{
+		{"pcm", NewFile(pcm), []FourByteString{ChunkTypeAudioDescription}},
+		{"opus", NewFile(opus), []FourByteString{ChunkTypeAudioDescription, ChunkTypePacketTable}},
+		{"pcm with layout", NewFileWithLayout(pcm, NewStereoChannelLayout()), []FourByteString{ChunkTypeAudioDescription, ChunkTypeChannelLayout}},
+		{"opus with layout", NewFileWithLayout(opus, NewStereoChannelLayout()), []FourByteString{ChunkTypeAudioDescription, ChunkTypeChannelLayout, ChunkTypePacketTable}},
+	}
+	for _, test := range tests {
+		if err := test.file.Validate(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		decoded := roundTrip(t, test.file)
+		if err := decoded.Validate(); err != nil {
+			t.Errorf("%s: decoded: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(chunkTypes(decoded), test.types) {
+			t.Errorf("%s: expected chunks %v, got %v", test.name, test.types, chunkTypes(decoded))
+		}
+		for i, c := range decoded.Chunks {
+			if c.Header != test.file.Chunks[i].Header {
+				t.Errorf("%s: expected header %+v, got %+v", test.name, test.file.Chunks[i].Header, c.Header)
+			}
+		}
+	}
+}
+
+func TestForEachChunk(t *testing.T) {
+	f := testFile()
+	var visited []int
+	f.ForEachChunk(func(index int, c *Chunk) bool {
+		visited = append(visited, index)
+		c.Header.ChunkSize = 0
+		return c.Header.ChunkType != ChunkTypeMidi
+	})
+	if !reflect.DeepEqual(visited, []int{0, 1}) {
+		t.Errorf("expected to stop at first midi chunk, visited %v", visited)
+	}
+	if f.Chunks[0].Header.ChunkSize != 0 || f.Chunks[1].Header.ChunkSize != 0 || f.Chunks[2].Header.ChunkSize == 0 {
+		t.Error("expected only visited chunks to be modified")
+	}
+}
+
+func TestFilterChunks(t *testing.T) {
+	f := testFile()
+	midi := f.FilterChunks(func(c Chunk) bool {
+		return c.Header.ChunkType == ChunkTypeMidi
+	})
+	if len(midi) != 2 {
+		t.Fatalf("expected 2 midi chunks, got %d", len(midi))
+	}
+	midi[0].Header.ChunkSize = 100
+	if f.Chunks[1].Header.ChunkSize == 100 {
+		t.Error("expected filtered chunks to be copies")
+	}
+	if none := f.FilterChunks(func(c Chunk) bool { return false }); len(none) != 0 {
+		t.Errorf("expected no chunks, got %d", len(none))
+	}
+}
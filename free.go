@@ -0,0 +1,58 @@
+package caf
+
+import (
+	"bufio"
+	"io"
+)
+
+// FreeSpaceChunk is padding reserved for later edits. Its bytes are always
+// written as zeros.
+type FreeSpaceChunk struct {
+	Size int64 `json:"size"`
+}
+
+func (c *FreeSpaceChunk) decode(r *bufio.Reader, h ChunkHeader) error {
+	n, err := io.CopyN(io.Discard, r, h.ChunkSize)
+	c.Size = n
+	if err == io.EOF {
+		return ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (c *FreeSpaceChunk) encode(w io.Writer) error {
+	if c.Size < 0 {
+		return ErrInvalidChunkSize
+	}
+	if n, err := w.Write(make([]byte, c.Size)); err != nil {
+		return err
+	} else if int64(n) != c.Size {
+		return io.ErrShortWrite
+	}
+	return nil
+}
+
+// NewFreeChunk returns a free chunk reserving size bytes. A negative size
+// returns ErrInvalidChunkSize.
+func NewFreeChunk(size int64) (Chunk, error) {
+	if size < 0 {
+		return Chunk{}, ErrInvalidChunkSize
+	}
+	return Chunk{
+		Header:   ChunkHeader{ChunkType: ChunkTypeFreeSpace, ChunkSize: size},
+		Contents: &FreeSpaceChunk{Size: size},
+	}, nil
+}
+
+// InsertFreeSpace inserts a free chunk of size bytes after the chunk at
+// afterIndex. An afterIndex of -1 inserts it before all other chunks.
+func (cf *File) InsertFreeSpace(afterIndex int, size int64) error {
+	if afterIndex < -1 || afterIndex >= len(cf.Chunks) {
+		return ErrChunkIndexOutOfRange
+	}
+	c, err := NewFreeChunk(size)
+	if err != nil {
+		return err
+	}
+	return cf.InsertChunkAt(afterIndex+1, c)
+}
@@ -0,0 +1,78 @@
+package caf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFreeSpaceChunk(t *testing.T) {
+	f := testFile()
+	if err := f.InsertFreeSpace(0, 16); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.InsertFreeSpace(-1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.InsertFreeSpace(len(f.Chunks)-1, 3); err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range []int{-2, len(f.Chunks)} {
+		if err := f.InsertFreeSpace(index, 1); err != ErrChunkIndexOutOfRange {
+			t.Errorf("%d: expected ErrChunkIndexOutOfRange, got %v", index, err)
+		}
+	}
+	expected := []FourByteString{
+		ChunkTypeFreeSpace,
+		ChunkTypeAudioDescription,
+		ChunkTypeFreeSpace,
+		ChunkTypeMidi,
+		ChunkTypeMidi,
+		ChunkTypeAudioData,
+		ChunkTypeFreeSpace,
+	}
+	if !reflect.DeepEqual(chunkTypes(f), expected) {
+		t.Fatalf("expected chunks %v, got %v", expected, chunkTypes(f))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != len(encodedTestFile(t))+3*chunkHeaderSize+16+3 {
+		t.Errorf("unexpected encoded size %d", buf.Len())
+	}
+	decoded := &File{}
+	if err := decoded.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, f) {
+		t.Errorf("expected decoded file to match, got:\n%s", decoded.Summary())
+	}
+	if free, ok := decoded.Chunks[2].AsFreeSpace(); !ok || free.Size != 16 {
+		t.Errorf("expected 16 bytes of free space, got %+v", free)
+	}
+}
+
+func freeChunk(t *testing.T, size int64) Chunk {
+	t.Helper()
+	c, err := NewFreeChunk(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNegativeFreeSpace(t *testing.T) {
+	if _, err := NewFreeChunk(-5); err != ErrInvalidChunkSize {
+		t.Errorf("expected ErrInvalidChunkSize, got %v", err)
+	}
+	f := testFile()
+	if err := f.InsertFreeSpace(0, -5); err != ErrInvalidChunkSize {
+		t.Errorf("expected ErrInvalidChunkSize, got %v", err)
+	}
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypeFreeSpace}, Contents: &FreeSpaceChunk{Size: -5}})
+	if err := f.Encode(&bytes.Buffer{}); err != ErrInvalidChunkSize {
+		t.Errorf("expected ErrInvalidChunkSize from Encode, got %v", err)
+	}
+}
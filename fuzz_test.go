@@ -0,0 +1,38 @@
+package caf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func FuzzDecodeFile(f *testing.F) {
+	sample, err := os.ReadFile("samples/helenkane.caf")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(sample)
+	f.Add([]byte("caff\x00\x01\x00\x00"))
+	for _, cf := range []*File{testFile(), vbrTestFile()} {
+		buf := &bytes.Buffer{}
+		if err := cf.Encode(buf); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		cf := &File{}
+		if err := cf.Decode(bytes.NewReader(b)); err != nil {
+			return
+		}
+		err := cf.Encode(io.Discard)
+		if len(cf.Chunks) == 0 {
+			if err != ErrNoChunks {
+				t.Errorf("expected ErrNoChunks encoding a file without chunks, got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("decoded file failed to encode: %v", err)
+		}
+	})
+}
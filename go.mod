@@ -1,5 +1,3 @@
 module github.com/pascoej/caf
 
-go 1.15
-
-require github.com/sirupsen/logrus v1.7.0
+go 1.18
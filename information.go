@@ -0,0 +1,166 @@
+package caf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// NewCAFStringsChunkFromMap returns an information chunk holding the entries
+// of m, ordered by key.
+func NewCAFStringsChunkFromMap(m map[string]string) *CAFStringsChunk {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	c := &CAFStringsChunk{}
+	for _, k := range keys {
+		c.Set(k, m[k])
+	}
+	return c
+}
+
+// ToMap returns the entries as a map. Later duplicates of a key win.
+func (c *CAFStringsChunk) ToMap() map[string]string {
+	m := make(map[string]string, len(c.Strings))
+	for _, info := range c.Strings {
+		m[info.Key] = info.Value
+	}
+	return m
+}
+
+// Len returns the number of entries and brings NumEntries up to date.
+func (c *CAFStringsChunk) Len() int {
+	c.NumEntries = uint32(len(c.Strings))
+	return len(c.Strings)
+}
+
+// Keys returns the keys of the entries in order.
+func (c *CAFStringsChunk) Keys() []string {
+	keys := make([]string, len(c.Strings))
+	for i, info := range c.Strings {
+		keys[i] = info.Key
+	}
+	return keys
+}
+
+// Values returns the values of the entries in order.
+func (c *CAFStringsChunk) Values() []string {
+	values := make([]string, len(c.Strings))
+	for i, info := range c.Strings {
+		values[i] = info.Value
+	}
+	return values
+}
+
+// Entries returns a copy of the entries.
+func (c *CAFStringsChunk) Entries() []Information {
+	return append([]Information(nil), c.Strings...)
+}
+
+// Get returns the value of the first entry with the given key.
+func (c *CAFStringsChunk) Get(key string) (string, bool) {
+	for _, info := range c.Strings {
+		if info.Key == key {
+			return info.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set updates the first entry with the given key, or appends a new entry if
+// there is none.
+func (c *CAFStringsChunk) Set(key, value string) {
+	for i := range c.Strings {
+		if c.Strings[i].Key == key {
+			c.Strings[i].Value = value
+			c.Len()
+			return
+		}
+	}
+	c.Strings = append(c.Strings, Information{Key: key, Value: value})
+	c.Len()
+}
+
+// Delete removes every entry with the given key and reports whether any
+// were present.
+func (c *CAFStringsChunk) Delete(key string) bool {
+	strings := c.Strings[:0]
+	for _, info := range c.Strings {
+		if info.Key != key {
+			strings = append(strings, info)
+		}
+	}
+	deleted := len(strings) != len(c.Strings)
+	c.Strings = strings
+	c.Len()
+	return deleted
+}
+
+// Validate checks that the entry can be written as a pair of NUL-terminated
+// strings: the key must not be empty and neither string may contain a NUL.
+func (c *Information) Validate() error {
+	if c.Key == "" || c.Key == "\x00" {
+		return ErrEmptyInformationKey
+	}
+	if strings.IndexByte(c.Key, 0) >= 0 {
+		return fmt.Errorf("%w in key %q", ErrEmbeddedNUL, c.Key)
+	}
+	if strings.IndexByte(c.Value, 0) >= 0 {
+		return fmt.Errorf("%w in value of %q", ErrEmbeddedNUL, c.Key)
+	}
+	if uint64(len(c.Key)) >= math.MaxUint32 || uint64(len(c.Value)) >= math.MaxUint32 {
+		return fmt.Errorf("%w: %q", ErrInformationTooLong, c.Key)
+	}
+	return nil
+}
+
+// Validate checks every entry, returning an error that lists all invalid
+// entries. errors.Is matches any of the problems found.
+func (c *CAFStringsChunk) Validate() error {
+	var errs multiError
+	for i := range c.Strings {
+		if err := c.Strings[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// AddInformation sets key to value in the first info chunk, creating the
+// chunk ahead of the audio data if the file has none.
+func (cf *File) AddInformation(key, value string) {
+	if c, ok := cf.ChunkByType(ChunkTypeInformation); ok {
+		if cc, ok := c.AsCAFStrings(); ok {
+			cc.Set(key, value)
+			c.UpdateSize()
+			return
+		}
+	}
+	cc := &CAFStringsChunk{}
+	cc.Set(key, value)
+	infoChunk := newChunk(ChunkTypeInformation, cc)
+	index := cf.IndexOfChunk(ChunkTypeAudioData)
+	if index < 0 {
+		index = len(cf.Chunks)
+	}
+	cf.InsertChunkAt(index, infoChunk)
+}
+
+// GetInformation returns the value of key from the first info chunk.
+func (cf *File) GetInformation(key string) (string, bool) {
+	c, ok := cf.ChunkByType(ChunkTypeInformation)
+	if !ok {
+		return "", false
+	}
+	cc, ok := c.AsCAFStrings()
+	if !ok {
+		return "", false
+	}
+	return cc.Get(key)
+}
@@ -0,0 +1,154 @@
+package caf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCAFStringsChunk(t *testing.T) {
+	c := NewCAFStringsChunkFromMap(map[string]string{"title": "Button Up Your Overcoat", "artist": "Helen Kane"})
+	if c.NumEntries != 2 || c.Strings[0].Key != "artist" {
+		t.Fatalf("unexpected chunk %+v", c)
+	}
+	if v, ok := c.Get("title"); !ok || v != "Button Up Your Overcoat" {
+		t.Errorf("unexpected title %q", v)
+	}
+	if _, ok := c.Get("year"); ok {
+		t.Error("expected no year")
+	}
+
+	c.Set("title", "I Wanna Be Loved By You")
+	c.Set("year", "1928")
+	if c.NumEntries != 3 || len(c.Strings) != 3 {
+		t.Errorf("expected 3 entries, got %d (%d)", c.NumEntries, len(c.Strings))
+	}
+	expected := map[string]string{"artist": "Helen Kane", "title": "I Wanna Be Loved By You", "year": "1928"}
+	if m := c.ToMap(); !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %v, got %v", expected, m)
+	}
+
+	if !c.Delete("artist") {
+		t.Error("expected artist to be deleted")
+	}
+	if c.Delete("artist") {
+		t.Error("expected artist to be absent")
+	}
+	if c.NumEntries != 2 || len(c.Strings) != 2 {
+		t.Errorf("expected 2 entries, got %d (%d)", c.NumEntries, len(c.Strings))
+	}
+}
+
+func TestFileInformation(t *testing.T) {
+	f := testFile()
+	if _, ok := f.GetInformation("title"); ok {
+		t.Error("expected no title")
+	}
+	f.AddInformation("title", "first")
+	f.AddInformation("artist", "Helen Kane")
+	f.AddInformation("title", "second")
+	expected := []FourByteString{ChunkTypeAudioDescription, ChunkTypeMidi, ChunkTypeMidi, ChunkTypeInformation, ChunkTypeAudioData}
+	if !reflect.DeepEqual(chunkTypes(f), expected) {
+		t.Errorf("expected chunks %v, got %v", expected, chunkTypes(f))
+	}
+	decoded := roundTrip(t, f)
+	if title, ok := decoded.GetInformation("title"); !ok || title != "second" {
+		t.Errorf("expected title second, got %q", title)
+	}
+	if artist, ok := decoded.GetInformation("artist"); !ok || artist != "Helen Kane" {
+		t.Errorf("expected artist Helen Kane, got %q", artist)
+	}
+	cc, _ := decoded.Chunks[3].AsCAFStrings()
+	if cc.NumEntries != 2 || len(cc.Strings) != 2 {
+		t.Errorf("expected 2 entries, got %d (%d)", cc.NumEntries, len(cc.Strings))
+	}
+}
+
+func TestReadString(t *testing.T) {
+	r := bufio.NewReader(bytes.NewReader([]byte("title\x00\x00abc")))
+	for _, expected := range []string{"title", ""} {
+		if s, err := readString(r); err != nil || s != expected {
+			t.Errorf("expected %q, got %q (%v)", expected, s, err)
+		}
+	}
+	if _, err := readString(r); err != io.EOF {
+		t.Errorf("expected io.EOF for unterminated string, got %v", err)
+	}
+}
+
+func TestCAFStringsChunkAccessors(t *testing.T) {
+	c := NewCAFStringsChunkFromMap(map[string]string{"title": "Button Up Your Overcoat", "artist": "Helen Kane"})
+	if keys := c.Keys(); !reflect.DeepEqual(keys, []string{"artist", "title"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	if values := c.Values(); !reflect.DeepEqual(values, []string{"Helen Kane", "Button Up Your Overcoat"}) {
+		t.Errorf("unexpected values %v", values)
+	}
+	entries := c.Entries()
+	entries[0].Value = "changed"
+	if c.Strings[0].Value != "Helen Kane" {
+		t.Error("modifying Entries changed the chunk")
+	}
+	c.Strings = append(c.Strings, Information{Key: "year", Value: "1929"})
+	if c.Len() != 3 || c.NumEntries != 3 {
+		t.Errorf("expected 3 entries, got %d (NumEntries %d)", c.Len(), c.NumEntries)
+	}
+}
+
+func TestCAFStringsChunkEncodeSyncsCount(t *testing.T) {
+	c := &CAFStringsChunk{NumEntries: 5, Strings: []Information{{Key: "title", Value: "a"}}}
+	buf := &bytes.Buffer{}
+	if err := c.encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded CAFStringsChunk
+	if err := decoded.decode(bufio.NewReader(buf)); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.NumEntries != 1 || !reflect.DeepEqual(decoded.Strings, c.Strings) {
+		t.Errorf("unexpected decoded chunk %+v", decoded)
+	}
+}
+
+func TestInformationValidate(t *testing.T) {
+	tests := []struct {
+		info     Information
+		expected error
+	}{
+		{Information{Key: "title", Value: "test"}, nil},
+		{Information{Key: "title"}, nil},
+		{Information{Value: "test"}, ErrEmptyInformationKey},
+		{Information{Key: "\x00", Value: "test"}, ErrEmptyInformationKey},
+		{Information{Key: "ti\x00tle", Value: "test"}, ErrEmbeddedNUL},
+		{Information{Key: "title", Value: "te\x00st"}, ErrEmbeddedNUL},
+	}
+	for _, test := range tests {
+		if err := test.info.Validate(); !errors.Is(err, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.info, test.expected, err)
+		}
+	}
+}
+
+func TestCAFStringsChunkValidate(t *testing.T) {
+	c := NewCAFStringsChunkFromMap(map[string]string{"artist": "a", "title": "b"})
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c.Strings = append(c.Strings, Information{Value: "c"}, Information{Key: "d", Value: "\x00"})
+	err := c.Validate()
+	if !errors.Is(err, ErrEmptyInformationKey) || !errors.Is(err, ErrEmbeddedNUL) {
+		t.Errorf("expected both violations, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "entry 2") || !strings.Contains(err.Error(), "entry 3") {
+		t.Errorf("expected entries 2 and 3 in %q", err)
+	}
+	f := testFile()
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypeInformation}, Contents: c})
+	if err := f.Validate(); !errors.Is(err, ErrEmptyInformationKey) {
+		t.Errorf("expected File.Validate to check information, got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package caf
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo encodes the file to w, implementing io.WriterTo.
+func (cf *File) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := cf.Encode(cw)
+	return cw.n, err
+}
+
+// ReadFrom decodes the file from r, implementing io.ReaderFrom. The count
+// includes any bytes read ahead by the decoder's buffering.
+func (cf *File) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	err := cf.Decode(cr)
+	return cr.n, err
+}
+
+// Decode reads a complete CAF file from r.
+func Decode(r io.Reader) (*File, error) {
+	f := &File{}
+	if err := f.Decode(r); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Encode writes f to w as a CAF file.
+func Encode(w io.Writer, f *File) error {
+	return f.Encode(w)
+}
+
+// Open reads the CAF file at path.
+func Open(path string) (*File, error) {
+	f := &File{}
+	if err := f.DecodeFile(path); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Save writes f to path, creating or truncating the file.
+func Save(path string, f *File) error {
+	return f.EncodeFile(path)
+}
+
+// DecodeFile reads the CAF file at path into the file.
+func (cf *File) DecodeFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return cf.Decode(file)
+}
+
+// EncodeFile writes the file to path, creating or truncating it.
+func (cf *File) EncodeFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := cf.Encode(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// SeekToChunk finds the first chunk of type t in the CAF file in r. It returns
+// the offset of that chunk's body and leaves r positioned there. If the file
+// was decoded from r by DecodeFrom, the recorded offset is used directly;
+// otherwise the chunk headers are scanned, seeking over their bodies.
+func (cf *File) SeekToChunk(t FourByteString, r io.ReadSeeker) (int64, error) {
+	if c, ok := cf.ChunkByType(t); ok && c.Offset > 0 {
+		if offset, ok := seekToRecordedChunk(t, c.Offset, r); ok {
+			return offset, nil
+		}
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var fileHeader FileHeader
+	if err := fileHeader.Decode(r); err != nil {
+		return 0, err
+	}
+	offset := int64(fileHeaderSize)
+	for {
+		var c Chunk
+		if err := c.decodeHeader(r); err == io.EOF {
+			return 0, ErrChunkNotFound
+		} else if err != nil {
+			return 0, &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: offset, Err: err}
+		}
+		offset += chunkHeaderSize
+		if c.Header.ChunkType == t {
+			return offset, nil
+		}
+		if c.Header.ChunkSize == -1 {
+			return 0, ErrChunkNotFound
+		}
+		var err error
+		if offset, err = r.Seek(c.Header.ChunkSize, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// seekToRecordedChunk checks that a chunk of type t starts at offset in r and
+// returns the offset of its body, leaving r positioned there.
+func seekToRecordedChunk(t FourByteString, offset int64, r io.ReadSeeker) (int64, bool) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, false
+	}
+	var c Chunk
+	if err := c.decodeHeader(r); err != nil || c.Header.ChunkType != t {
+		return 0, false
+	}
+	return offset + chunkHeaderSize, true
+}
+
+// DecodeChunkAt decodes the chunk of type t whose body starts at offset in r,
+// as returned by SeekToChunk.
+func (cf *File) DecodeChunkAt(r io.ReadSeeker, offset int64, t FourByteString) (*Chunk, error) {
+	if offset < fileHeaderSize+chunkHeaderSize {
+		return nil, ErrChunkNotFound
+	}
+	if _, err := r.Seek(offset-chunkHeaderSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var c Chunk
+	if err := c.decodeHeader(r); err == io.EOF {
+		return nil, ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	if c.Header.ChunkType != t {
+		return nil, ErrChunkNotFound
+	}
+	if err := c.decodeContents(bufio.NewReader(r)); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// VerifyRoundTrip encodes the file, decodes the result and checks that it
+// equals the file, returning a *RoundTripError describing any difference.
+// Chunk sizes are compared against the sizes Encode writes rather than the
+// stored headers, so files edited through the API verify cleanly.
+func (cf *File) VerifyRoundTrip() error {
+	encoded := &bytes.Buffer{}
+	if err := cf.Encode(encoded); err != nil {
+		return err
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader(encoded.Bytes())); err != nil {
+		return err
+	}
+	want := cf.Clone()
+	for i := range want.Chunks {
+		c := &want.Chunks[i]
+		if c.Header.ChunkType == ChunkTypeAudioData && c.Header.ChunkSize == -1 && i == len(want.Chunks)-1 {
+			continue
+		}
+		size, err := c.ContentEncodedSize()
+		if err != nil {
+			return err
+		}
+		c.Header.ChunkSize = size
+	}
+	if want.Equal(decoded) {
+		return nil
+	}
+	diffs, err := want.Diff(decoded)
+	if err != nil {
+		return err
+	}
+	offset := int64(-1)
+	reencoded := &bytes.Buffer{}
+	if err := decoded.Encode(reencoded); err == nil {
+		offset = firstDifference(encoded.Bytes(), reencoded.Bytes())
+	}
+	return &RoundTripError{
+		HeaderChanged: cf.FileHeader != decoded.FileHeader,
+		Diffs:         diffs,
+		Offset:        offset,
+	}
+}
+
+// firstDifference returns the first offset at which a and b differ, or -1 if
+// they are equal.
+func firstDifference(a, b []byte) int64 {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return int64(i)
+		}
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return int64(len(a))
+		}
+		return int64(len(b))
+	}
+	return -1
+}
@@ -0,0 +1,214 @@
+package caf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	_ io.WriterTo   = &File{}
+	_ io.ReaderFrom = &File{}
+)
+
+func TestWriteToReadFrom(t *testing.T) {
+	encoded := encodedTestFile(t)
+	buf := &bytes.Buffer{}
+	n, err := testFile().WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(encoded)) || !bytes.Equal(buf.Bytes(), encoded) {
+		t.Errorf("expected %d bytes written, got %d", len(encoded), n)
+	}
+
+	f := &File{}
+	n, err = f.ReadFrom(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(encoded)) {
+		t.Errorf("expected %d bytes read, got %d", len(encoded), n)
+	}
+	if len(f.Chunks) != len(testFile().Chunks) {
+		t.Errorf("expected %d chunks, got %d", len(testFile().Chunks), len(f.Chunks))
+	}
+}
+
+func TestDecodeEncode(t *testing.T) {
+	f, err := Decode(bytes.NewReader(encodedTestFile(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, f); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), encodedTestFile(t)) {
+		t.Error("re-encoded file differs")
+	}
+	if _, err := Decode(bytes.NewReader(nil)); err == nil {
+		t.Error("expected error decoding empty input")
+	}
+}
+
+func TestOpenSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.caf")
+	if err := Save(path, testFile()); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Chunks) != len(testFile().Chunks) {
+		t.Errorf("expected %d chunks, got %d", len(testFile().Chunks), len(f.Chunks))
+	}
+}
+
+func TestDecodeEncodeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.caf")
+	if err := testFile().EncodeFile(path); err != nil {
+		t.Fatal(err)
+	}
+	f := &File{}
+	if err := f.DecodeFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Equal(roundTrip(t, testFile())) {
+		t.Error("decoded file does not match")
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.caf")
+	var pathErr *os.PathError
+	if err := f.DecodeFile(missing); !errors.As(err, &pathErr) || pathErr.Path != missing {
+		t.Errorf("expected path error for %s, got %v", missing, err)
+	}
+	if err := f.EncodeFile(filepath.Join(missing, "nested.caf")); !errors.As(err, &pathErr) {
+		t.Errorf("expected path error, got %v", err)
+	}
+}
+
+func TestSeekToChunk(t *testing.T) {
+	f := allChunkTypesFile(t)
+	buf := &bytes.Buffer{}
+	if err := f.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	for _, i := range []int{0, 3, 6, 9} {
+		expected := f.Chunks[i]
+		offset, err := f.SeekToChunk(expected.Header.ChunkType, r)
+		if err != nil {
+			t.Fatalf("%v: %v", expected.Header.ChunkType, err)
+		}
+		c, err := (&File{}).DecodeChunkAt(r, offset, expected.Header.ChunkType)
+		if err != nil {
+			t.Fatalf("%v: %v", expected.Header.ChunkType, err)
+		}
+		if !c.Equal(&expected) {
+			t.Errorf("%v: expected %+v, got %+v", expected.Header.ChunkType, expected, c)
+		}
+	}
+	if _, err := f.SeekToChunk(stringToChunkType("abcd"), r); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+	offset, _ := f.SeekToChunk(ChunkTypeMidi, r)
+	if _, err := f.DecodeChunkAt(r, offset, ChunkTypeInformation); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound for mismatched type, got %v", err)
+	}
+}
+
+func TestDecodeFrom(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := allChunkTypesFile(t).Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewBuffer(encoded)); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range decoded.Chunks {
+		if c.Offset != 0 {
+			t.Errorf("%v: expected no offset from a plain reader, got %d", c.Header.ChunkType, c.Offset)
+		}
+	}
+
+	const prefix = 5
+	r := bytes.NewReader(append(make([]byte, prefix), encoded...))
+	if _, err := r.Seek(prefix, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	f := &File{}
+	if err := f.DecodeFrom(r); err != nil {
+		t.Fatal(err)
+	}
+	offset := int64(prefix + fileHeaderSize)
+	for _, c := range f.Chunks {
+		if c.Offset != offset {
+			t.Errorf("%v: expected offset %d, got %d", c.Header.ChunkType, offset, c.Offset)
+		}
+		offset += chunkHeaderSize + c.Header.ChunkSize
+	}
+	if !f.Equal(decoded) {
+		t.Error("expected offsets to be ignored by Equal")
+	}
+
+	markers, _ := f.ChunkByType(ChunkTypeMarkers)
+	bodyOffset, err := f.SeekToChunk(ChunkTypeMarkers, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bodyOffset != markers.Offset+chunkHeaderSize {
+		t.Errorf("expected body at %d, got %d", markers.Offset+chunkHeaderSize, bodyOffset)
+	}
+	c, err := f.DecodeChunkAt(r, bodyOffset, ChunkTypeMarkers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Equal(markers) {
+		t.Errorf("expected %+v, got %+v", markers, c)
+	}
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	if err := roundTrip(t, allChunkTypesFile(t)).VerifyRoundTrip(); err != nil {
+		t.Errorf("expected decoded file to round trip, got %v", err)
+	}
+	f := testFile()
+	f.Chunks[1].Header.ChunkSize = 5
+	if err := f.VerifyRoundTrip(); err != nil {
+		t.Errorf("expected stale chunk size to be ignored, got %v", err)
+	}
+	af, err := NewStereoAudioFormat(44100, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = NewFile(af)
+	f.Chunks = append(f.Chunks, NewAudioDataChunk(nil, 0))
+	f.Chunks[1].Contents.(*Data).Append([]byte{1, 2, 3, 4})
+	if err := f.VerifyRoundTrip(); err != nil {
+		t.Errorf("expected appended data to round trip, got %v", err)
+	}
+
+	f = testFile()
+	f.Chunks = append(f.Chunks, NewChannelLayoutChunk(ChannelLayout{
+		ChannelLayoutTag: ChannelLayoutTagUseChannelDescriptions,
+		Channels:         []ChannelDescription{{ChannelLabel: 1}},
+	}))
+	err = f.VerifyRoundTrip()
+	var roundTripErr *RoundTripError
+	if !errors.As(err, &roundTripErr) {
+		t.Fatalf("expected round trip error, got %v", err)
+	}
+	if len(roundTripErr.Diffs) != 1 || roundTripErr.Diffs[0].Index != 4 || roundTripErr.HeaderChanged {
+		t.Errorf("expected chan chunk to differ, got %v", roundTripErr.Diffs)
+	}
+	if roundTripErr.Offset != -1 {
+		t.Errorf("expected encodings to match, got offset %d", roundTripErr.Offset)
+	}
+}
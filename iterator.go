@@ -0,0 +1,83 @@
+package caf
+
+import (
+	"bufio"
+	"io"
+)
+
+// ChunkIterator steps through the chunks of a file one at a time.
+type ChunkIterator struct {
+	header FileHeader
+	chunks []Chunk
+	reader *bufio.Reader
+	offset int64
+	err    error
+}
+
+// NewChunkIterator returns an iterator over the already decoded chunks of the
+// file.
+func (cf *File) NewChunkIterator() *ChunkIterator {
+	return &ChunkIterator{header: cf.FileHeader, chunks: cf.Chunks}
+}
+
+// NewStreamingChunkIterator reads the file header from r and returns an
+// iterator that decodes one chunk from r per call to Next.
+func NewStreamingChunkIterator(r io.Reader) (*ChunkIterator, error) {
+	bufferedReader := bufio.NewReader(r)
+	var header FileHeader
+	if err := header.Decode(bufferedReader); err != nil {
+		return nil, err
+	}
+	return &ChunkIterator{header: header, reader: bufferedReader, offset: fileHeaderSize}, nil
+}
+
+// FileHeader returns the header of the file being iterated.
+func (it *ChunkIterator) FileHeader() FileHeader {
+	return it.header
+}
+
+// Next returns the next chunk. It returns false once there are no more
+// chunks or decoding fails; Err tells the two apart.
+func (it *ChunkIterator) Next() (Chunk, bool) {
+	if it.reader == nil {
+		if len(it.chunks) == 0 {
+			return Chunk{}, false
+		}
+		c := it.chunks[0]
+		it.chunks = it.chunks[1:]
+		return c, true
+	}
+	if it.err != nil {
+		return Chunk{}, false
+	}
+	var c Chunk
+	if err := c.decodeHeader(it.reader); err == io.EOF {
+		it.reader = nil
+		return Chunk{}, false
+	} else if err != nil {
+		it.err = &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: it.offset, Err: err}
+		return Chunk{}, false
+	}
+	if err := c.decodeContents(it.reader); err != nil {
+		it.err = &ChunkDecodeError{ChunkType: c.Header.ChunkType, Offset: it.offset, Err: err}
+		return Chunk{}, false
+	}
+	it.offset += chunkHeaderSize + c.Header.ChunkSize
+	return c, true
+}
+
+// Err returns the error that stopped a streaming iterator, if any.
+func (it *ChunkIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and discards any buffered input. It does not
+// close the underlying reader.
+func (it *ChunkIterator) Close() error {
+	if it.reader != nil {
+		it.reader.Reset(nil)
+		it.reader = nil
+	}
+	it.chunks = nil
+	return nil
+}
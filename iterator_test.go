@@ -0,0 +1,63 @@
+package caf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestChunkIterator(t *testing.T) {
+	f := testFile()
+	it := f.NewChunkIterator()
+	var got []Chunk
+	for c, ok := it.Next(); ok; c, ok = it.Next() {
+		got = append(got, c)
+	}
+	if len(got) != len(f.Chunks) || it.Err() != nil {
+		t.Fatalf("expected %d chunks, got %d (%v)", len(f.Chunks), len(got), it.Err())
+	}
+
+	it = f.NewChunkIterator()
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := it.Next(); ok {
+		t.Error("expected no chunks after Close")
+	}
+}
+
+func TestStreamingChunkIterator(t *testing.T) {
+	encoded := encodedTestFile(t)
+	it, err := NewStreamingChunkIterator(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it.FileHeader() != testFile().FileHeader {
+		t.Errorf("unexpected file header %+v", it.FileHeader())
+	}
+	expected := testFile()
+	var i int
+	for c, ok := it.Next(); ok; c, ok = it.Next() {
+		if !c.Equal(&expected.Chunks[i]) {
+			t.Errorf("chunk %d: expected %+v, got %+v", i, expected.Chunks[i], c)
+		}
+		i++
+	}
+	if i != len(expected.Chunks) || it.Err() != nil {
+		t.Errorf("expected %d chunks, got %d (%v)", len(expected.Chunks), i, it.Err())
+	}
+
+	it, err = NewStreamingChunkIterator(bytes.NewReader(encoded[:len(encoded)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+	}
+	if !errors.Is(it.Err(), ErrUnexpectedEOF) {
+		t.Errorf("expected ErrUnexpectedEOF, got %v", it.Err())
+	}
+
+	if _, err := NewStreamingChunkIterator(bytes.NewReader([]byte("RIFF\x00\x01\x00\x00"))); err != ErrInvalidFileType {
+		t.Errorf("expected ErrInvalidFileType, got %v", err)
+	}
+}
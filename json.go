@@ -0,0 +1,107 @@
+package caf
+
+import (
+	"encoding/json"
+)
+
+type fileJSON struct {
+	FileHeader FileHeader  `json:"fileHeader"`
+	Chunks     []chunkJSON `json:"chunks"`
+}
+
+type chunkJSON struct {
+	Type     FourByteString  `json:"type"`
+	Size     int64           `json:"size"`
+	Contents json.RawMessage `json:"contents,omitempty"`
+}
+
+// dataJSON stands in for Data, which is described only by its length.
+type dataJSON struct {
+	EditCount uint32 `json:"editCount"`
+	ByteCount int    `json:"byteCount"`
+}
+
+// MarshalJSON describes the file header and chunks as JSON. Audio data is
+// replaced by its length. It has a value receiver so that a File marshalled
+// by value, or embedded in another struct, is described the same way.
+func (cf File) MarshalJSON() ([]byte, error) {
+	out := fileJSON{FileHeader: cf.FileHeader, Chunks: make([]chunkJSON, 0, len(cf.Chunks))}
+	for _, c := range cf.Chunks {
+		contents := c.Contents
+		if cc, ok := contents.(*Data); ok {
+			contents = dataJSON{EditCount: cc.EditCount, ByteCount: len(cc.Data)}
+		}
+		encoded, err := json.Marshal(contents)
+		if err != nil {
+			return nil, err
+		}
+		out.Chunks = append(out.Chunks, chunkJSON{Type: c.Header.ChunkType, Size: c.Header.ChunkSize, Contents: encoded})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a file described by MarshalJSON. Data chunks come
+// back with their edit count but no audio.
+func (cf *File) UnmarshalJSON(b []byte) error {
+	var in fileJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	chunks := make([]Chunk, 0, len(in.Chunks))
+	for _, cj := range in.Chunks {
+		c := Chunk{Header: ChunkHeader{ChunkType: cj.Type, ChunkSize: cj.Size}}
+		if err := c.unmarshalContentsJSON(cj.Contents); err != nil {
+			return err
+		}
+		chunks = append(chunks, c)
+	}
+	cf.FileHeader = in.FileHeader
+	cf.Chunks = chunks
+	return nil
+}
+
+func (c *Chunk) unmarshalContentsJSON(b json.RawMessage) error {
+	if len(b) == 0 || string(b) == "null" {
+		return nil
+	}
+	var contents interface{}
+	switch c.Header.ChunkType {
+	case ChunkTypeAudioDescription:
+		contents = &AudioFormat{}
+	case ChunkTypeChannelLayout:
+		contents = &ChannelLayout{}
+	case ChunkTypeInformation:
+		contents = &CAFStringsChunk{}
+	case ChunkTypeAudioData:
+		var dj dataJSON
+		if err := json.Unmarshal(b, &dj); err != nil {
+			return err
+		}
+		c.Contents = &Data{EditCount: dj.EditCount}
+		return nil
+	case ChunkTypePacketTable:
+		contents = &PacketTable{}
+	case ChunkTypeMidi:
+		var cc Midi
+		if err := json.Unmarshal(b, &cc); err != nil {
+			return err
+		}
+		c.Contents = cc
+		return nil
+	case ChunkTypeFreeSpace:
+		contents = &FreeSpaceChunk{}
+	case ChunkTypeMarkers:
+		contents = &MarkerChunk{}
+	case ChunkTypeRegion:
+		contents = &RegionChunk{}
+	case ChunkTypePeak:
+		contents = &PeakChunk{}
+	default:
+		contents = &UnknownContents{}
+	}
+	if err := json.Unmarshal(b, contents); err != nil {
+		return err
+	}
+	c.Contents = contents
+	return nil
+}
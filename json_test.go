@@ -0,0 +1,55 @@
+package caf
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFileJSON(t *testing.T) {
+	f := allChunkTypesFile(t)
+	encoded, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"byteCount":8`) {
+		t.Errorf("expected data byte count in %s", encoded)
+	}
+	if key := regexp.MustCompile(`"[A-Z]\w*":`).Find(encoded); key != nil {
+		t.Errorf("expected lowerCamel keys, found %s", key)
+	}
+	var decoded File
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.FileHeader != f.FileHeader || len(decoded.Chunks) != len(f.Chunks) {
+		t.Fatalf("expected %+v with %d chunks, got %+v with %d", f.FileHeader, len(f.Chunks), decoded.FileHeader, len(decoded.Chunks))
+	}
+	for i := range f.Chunks {
+		expected := f.Chunks[i]
+		if data, ok := expected.AsData(); ok {
+			expected.Contents = &Data{EditCount: data.EditCount}
+		}
+		if !decoded.Chunks[i].Equal(&expected) {
+			t.Errorf("chunk %d: expected %+v, got %+v", i, expected, decoded.Chunks[i])
+		}
+	}
+}
+
+func TestFileJSONByValue(t *testing.T) {
+	f := allChunkTypesFile(t)
+	wrapped := struct {
+		Name string `json:"name"`
+		File File   `json:"file"`
+	}{"test", *f}
+	for _, v := range []interface{}{*f, wrapped} {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(encoded), `"contents":{"editCount":0,"byteCount":8}`) {
+			t.Errorf("expected audio data to be replaced by its length in %s", encoded)
+		}
+	}
+}
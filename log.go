@@ -0,0 +1,21 @@
+package caf
+
+// Logger receives diagnostic messages from the decoder and encoder.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+
+var logger Logger = nopLogger{}
+
+// SetLogger sets the logger used by the package. Passing nil restores the
+// default, which discards all messages.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger = l
+}
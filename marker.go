@@ -0,0 +1,66 @@
+package caf
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+var ChunkTypeMarkers = stringToChunkType("mark")
+
+// SMPTETime is a SMPTE timestamp attached to a marker.
+type SMPTETime struct {
+	Hours                int8   `json:"hours"`
+	Minutes              uint8  `json:"minutes"`
+	Seconds              uint8  `json:"seconds"`
+	Frames               uint8  `json:"frames"`
+	SubFrameSampleOffset uint32 `json:"subFrameSampleOffset"`
+}
+
+// Marker is a named position in the audio. Names are not stored in the
+// marker itself but in a strg chunk keyed by ID.
+type Marker struct {
+	Type          uint32    `json:"type"`
+	FramePosition float64   `json:"framePosition"`
+	ID            uint32    `json:"id"`
+	SMPTETime     SMPTETime `json:"smpteTime"`
+	Channel       uint32    `json:"channel"`
+}
+
+// MarkerChunk holds the contents of a mark chunk.
+type MarkerChunk struct {
+	SMPTETimeType uint32   `json:"smpteTimeType"`
+	NumberMarkers uint32   `json:"numberMarkers"`
+	Markers       []Marker `json:"markers"`
+}
+
+func (c *MarkerChunk) decode(r io.Reader) error {
+	if err := binary.Read(r, binary.BigEndian, &c.SMPTETimeType); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.BigEndian, &c.NumberMarkers); err != nil {
+		return err
+	}
+	for i := uint32(0); i < c.NumberMarkers; i++ {
+		var marker Marker
+		if err := binary.Read(r, binary.BigEndian, &marker); err != nil {
+			return err
+		}
+		c.Markers = append(c.Markers, marker)
+	}
+	return nil
+}
+
+func (c *MarkerChunk) encode(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, &c.SMPTETimeType); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, &c.NumberMarkers); err != nil {
+		return err
+	}
+	for i := uint32(0); i < c.NumberMarkers; i++ {
+		if err := binary.Write(w, binary.BigEndian, &c.Markers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package caf
+
+import (
+	"testing"
+)
+
+func TestMarkerChunkRoundTrip(t *testing.T) {
+	f := testFile()
+	markers := &MarkerChunk{
+		SMPTETimeType: 30,
+		NumberMarkers: 2,
+		Markers: []Marker{
+			{Type: stringToUint32("mrkr"), FramePosition: 0, ID: 1},
+			{Type: stringToUint32("rbeg"), FramePosition: 44100.5, ID: 2, SMPTETime: SMPTETime{Hours: 1, Minutes: 2, Seconds: 3, Frames: 4, SubFrameSampleOffset: 5}, Channel: 1},
+		},
+	}
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypeMarkers, ChunkSize: 8 + 2*28}, Contents: markers})
+	decoded := roundTrip(t, f)
+	if !decoded.Equal(f) {
+		t.Errorf("expected decoded file to match:\n%s\ngot:\n%s", f.Summary(), decoded.Summary())
+	}
+	c := &decoded.Chunks[len(decoded.Chunks)-1]
+	if cc, ok := c.AsMarkers(); !ok || cc.Markers[1].FramePosition != 44100.5 {
+		t.Errorf("unexpected markers %+v", cc)
+	}
+	if c.TypeString() != "Markers" {
+		t.Errorf("unexpected type string %q", c.TypeString())
+	}
+}
+
+func stringToUint32(s string) uint32 {
+	t := stringToChunkType(s)
+	return uint32(t[0])<<24 | uint32(t[1])<<16 | uint32(t[2])<<8 | uint32(t[3])
+}
@@ -0,0 +1,224 @@
+package caf
+
+import (
+	"errors"
+)
+
+// ByteOffsetForPacket returns the offset of packet index within the audio
+// data, which is the sum of the sizes of all preceding packets. The running
+// sums are computed on first use and reused until Entry is replaced, grown or
+// shrunk, or changed through AddEntry, Reset or Trim. Writing to an element
+// of Entry in place is not detected; assign a new slice instead.
+func (c *PacketTable) ByteOffsetForPacket(index int) (int64, error) {
+	if err := c.checkPacketIndex(index); err != nil {
+		return 0, err
+	}
+	return c.byteOffset(index), nil
+}
+
+// AccumulatedByteOffset returns the total size of the packets before index.
+// Unlike ByteOffsetForPacket, index may equal the number of packets, giving
+// the size of all the audio data. It shares ByteOffsetForPacket's running
+// sums.
+func (c *PacketTable) AccumulatedByteOffset(index int) (int64, error) {
+	if int64(len(c.Entry)) < c.Header.NumberPackets {
+		return 0, ErrPacketCountMismatch
+	}
+	if index < 0 || int64(index) > c.Header.NumberPackets {
+		return 0, ErrPacketIndexOutOfRange
+	}
+	return c.byteOffset(index), nil
+}
+
+// packetOffsets is the running sum of a packet table's entries. It records
+// the Entry slice it was built from so that a replaced slice is noticed, and
+// is never modified once stored, so readers can share it.
+type packetOffsets struct {
+	entry []uint64
+	sums  []int64
+}
+
+func (o *packetOffsets) matches(entry []uint64) bool {
+	if o == nil || len(o.entry) != len(entry) {
+		return false
+	}
+	return len(entry) == 0 || &o.entry[0] == &entry[0]
+}
+
+// byteOffset returns the total size of the packets before index, building
+// the running sums if the stored ones do not match Entry. The sums are
+// swapped in atomically, so concurrent readers are safe.
+func (c *PacketTable) byteOffset(index int) int64 {
+	offsets, _ := c.offsets.Load().(*packetOffsets)
+	if !offsets.matches(c.Entry) {
+		offsets = &packetOffsets{entry: c.Entry, sums: make([]int64, len(c.Entry)+1)}
+		for i, size := range c.Entry {
+			offsets.sums[i+1] = offsets.sums[i] + int64(size)
+		}
+		c.offsets.Store(offsets)
+	}
+	return offsets.sums[index]
+}
+
+// clearOffsets drops the running sums after the entries change.
+func (c *PacketTable) clearOffsets() {
+	if offsets, _ := c.offsets.Load().(*packetOffsets); offsets != nil {
+		c.offsets.Store((*packetOffsets)(nil))
+	}
+}
+
+// NumberPackets returns the larger of the header packet count and the number
+// of entries, logging when the two disagree.
+func (c *PacketTable) NumberPackets() int64 {
+	n := int64(len(c.Entry))
+	if c.Header.NumberPackets != n {
+		logger.Debugf("Packet table header has %d packets but %d entries", c.Header.NumberPackets, n)
+		if c.Header.NumberPackets > n {
+			return c.Header.NumberPackets
+		}
+	}
+	return n
+}
+
+// IsVBR reports whether the packets differ in size.
+func (c *PacketTable) IsVBR() bool {
+	for _, entry := range c.Entry {
+		if entry != c.Entry[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns a copy of the packet sizes.
+func (c *PacketTable) Entries() []uint64 {
+	return append([]uint64(nil), c.Entry...)
+}
+
+// FrameOffsetForPacket returns the first frame of packet index for a format
+// with framesPerPacket frames in every packet.
+func (c *PacketTable) FrameOffsetForPacket(index int, framesPerPacket uint32) (int64, error) {
+	if err := c.checkPacketIndex(index); err != nil {
+		return 0, err
+	}
+	return int64(index) * int64(framesPerPacket), nil
+}
+
+func (c *PacketTable) checkPacketIndex(index int) error {
+	if int64(len(c.Entry)) < c.Header.NumberPackets {
+		return ErrPacketCountMismatch
+	}
+	if index < 0 || int64(index) >= c.Header.NumberPackets {
+		return ErrPacketIndexOutOfRange
+	}
+	return nil
+}
+
+// BuildPacketTableFromCBR returns a packet table describing numPackets
+// packets of packetByteSize bytes each. Frames beyond validFrames in the
+// last packets are recorded as remainder frames.
+func BuildPacketTableFromCBR(packetByteSize uint64, numPackets int64, framesPerPacket uint32, validFrames int64) (*PacketTable, error) {
+	if numPackets <= 0 {
+		return nil, errors.New("number of packets must be positive")
+	}
+	if packetByteSize == 0 {
+		return nil, errors.New("packet size must be positive")
+	}
+	totalFrames := numPackets * int64(framesPerPacket)
+	if validFrames < 0 || validFrames > totalFrames {
+		return nil, errors.New("valid frames out of range")
+	}
+	entries := make([]uint64, numPackets)
+	for i := range entries {
+		entries[i] = packetByteSize
+	}
+	return &PacketTable{
+		Header: PacketTableHeader{
+			NumberPackets:     numPackets,
+			NumberValidFrames: validFrames,
+			RemainderFrames:   int32(totalFrames - validFrames),
+		},
+		Entry: entries,
+	}, nil
+}
+
+// AddEntry appends a packet of byteCount bytes to the table. Call UpdateSize on the
+// chunk holding c afterwards to keep its header size in step.
+func (c *PacketTable) AddEntry(byteCount uint64) {
+	c.Entry = append(c.Entry, byteCount)
+	c.Header.NumberPackets = int64(len(c.Entry))
+	c.clearOffsets()
+}
+
+// Reset removes all packets and clears the frame counts.
+func (c *PacketTable) Reset() {
+	c.Entry = c.Entry[:0]
+	c.clearOffsets()
+	c.Header = PacketTableHeader{}
+}
+
+// Trim keeps only packets startPacket up to but not including endPacket. The
+// valid frame count becomes the full length of the kept packets, with no
+// priming or remainder frames.
+func (c *PacketTable) Trim(startPacket, endPacket, framesPerPacket int64) error {
+	if startPacket < 0 || startPacket > endPacket || endPacket > c.Header.NumberPackets {
+		return ErrPacketIndexOutOfRange
+	}
+	if int64(len(c.Entry)) != c.Header.NumberPackets {
+		return ErrPacketCountMismatch
+	}
+	c.Entry = c.Entry[startPacket:endPacket]
+	c.clearOffsets()
+	c.Header = PacketTableHeader{
+		NumberPackets:     endPacket - startPacket,
+		NumberValidFrames: (endPacket - startPacket) * framesPerPacket,
+	}
+	return nil
+}
+
+const packetTableHeaderSize = 24
+
+// TotalFrames returns the number of frames the packets hold: the valid
+// frames plus the priming and remainder frames around them.
+func (h PacketTableHeader) TotalFrames() int64 {
+	return h.NumberValidFrames + int64(h.PrimingFrames) + int64(h.RemainderFrames)
+}
+
+// TotalFrames returns the number of frames the packets hold, see
+// PacketTableHeader.TotalFrames.
+func (c *PacketTable) TotalFrames() int64 {
+	return c.Header.TotalFrames()
+}
+
+// VLQSizeOf returns the number of bytes v occupies as a packet table entry,
+// seven bits per byte. Entries of 1<<63 or more need ten bytes, which is
+// more than a packet table may hold, and fail to encode with ErrVLQOverflow.
+func VLQSizeOf(v uint64) int {
+	size := 1
+	for v >>= 7; v != 0; v >>= 7 {
+		size++
+	}
+	return size
+}
+
+// VLQEncodedSize is the same as VLQSizeOf.
+//
+// Deprecated: Use VLQSizeOf.
+func VLQEncodedSize(v uint64) int {
+	return VLQSizeOf(v)
+}
+
+// EncodedEntriesSize returns the number of bytes the entries occupy when
+// encoded.
+func (c *PacketTable) EncodedEntriesSize() int {
+	size := 0
+	for _, entry := range c.Entry {
+		size += VLQSizeOf(entry)
+	}
+	return size
+}
+
+// EncodedSize returns the size of the encoded pakt chunk body.
+func (c *PacketTable) EncodedSize() int {
+	return packetTableHeaderSize + c.EncodedEntriesSize()
+}
@@ -0,0 +1,372 @@
+package caf
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestByteOffsetForPacket(t *testing.T) {
+	pt := &PacketTable{Header: PacketTableHeader{NumberPackets: 3}, Entry: []uint64{10, 20, 30}}
+	for index, expected := range []int64{0, 10, 30} {
+		if offset, err := pt.ByteOffsetForPacket(index); err != nil {
+			t.Fatal(err)
+		} else if offset != expected {
+			t.Errorf("packet %d: expected byte offset %d, got %d", index, expected, offset)
+		}
+		if offset, err := pt.FrameOffsetForPacket(index, 1024); err != nil {
+			t.Fatal(err)
+		} else if offset != int64(index)*1024 {
+			t.Errorf("packet %d: expected frame offset %d, got %d", index, index*1024, offset)
+		}
+	}
+	for _, index := range []int{-1, 3} {
+		if _, err := pt.ByteOffsetForPacket(index); err != ErrPacketIndexOutOfRange {
+			t.Errorf("packet %d: expected ErrPacketIndexOutOfRange, got %v", index, err)
+		}
+		if _, err := pt.FrameOffsetForPacket(index, 1024); err != ErrPacketIndexOutOfRange {
+			t.Errorf("packet %d: expected ErrPacketIndexOutOfRange, got %v", index, err)
+		}
+	}
+	pt.Header.NumberPackets = 4
+	if _, err := pt.ByteOffsetForPacket(0); err != ErrPacketCountMismatch {
+		t.Errorf("expected ErrPacketCountMismatch, got %v", err)
+	}
+}
+
+func benchmarkPacketTable(numPackets int) *PacketTable {
+	pt := &PacketTable{Header: PacketTableHeader{NumberPackets: int64(numPackets)}}
+	for i := 0; i < numPackets; i++ {
+		pt.Entry = append(pt.Entry, uint64(100+i%50))
+	}
+	return pt
+}
+
+func BenchmarkByteOffsetForPacket(b *testing.B) {
+	pt := benchmarkPacketTable(500000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pt.ByteOffsetForPacket((i * 7919) % 500000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFrameOffsetForPacket(b *testing.B) {
+	pt := benchmarkPacketTable(500000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pt.FrameOffsetForPacket((i*7919)%500000, 1024); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBuildPacketTableFromCBR(t *testing.T) {
+	pt, err := BuildPacketTableFromCBR(4, 3, 1024, 3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PacketTable{
+		Header: PacketTableHeader{NumberPackets: 3, NumberValidFrames: 3000, RemainderFrames: 72},
+		Entry:  []uint64{4, 4, 4},
+	}
+	built, manual := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := pt.encode(built); err != nil {
+		t.Fatal(err)
+	}
+	if err := expected.encode(manual); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(built.Bytes(), manual.Bytes()) {
+		t.Errorf("expected %x, got %x", manual.Bytes(), built.Bytes())
+	}
+
+	for _, args := range []struct {
+		size        uint64
+		packets     int64
+		validFrames int64
+	}{{4, 0, 0}, {0, 3, 0}, {4, 3, 4000}, {4, 3, -1}} {
+		if _, err := BuildPacketTableFromCBR(args.size, args.packets, 1024, args.validFrames); err == nil {
+			t.Errorf("%+v: expected error", args)
+		}
+	}
+}
+
+func TestPrimingFramesRoundTrip(t *testing.T) {
+	f := testFile()
+	f.Chunks = append(f.Chunks, Chunk{
+		Header: ChunkHeader{ChunkType: ChunkTypePacketTable},
+		Contents: &PacketTable{
+			Header: PacketTableHeader{NumberPackets: 2, NumberValidFrames: 1200, PrimingFrames: 312, RemainderFrames: 408},
+			Entry:  []uint64{4, 4},
+		},
+	})
+	encoded := &bytes.Buffer{}
+	if err := f.Encode(encoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &File{}
+	if err := decoded.Decode(bytes.NewReader(encoded.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := decoded.ChunkByType(ChunkTypePacketTable)
+	if priming := c.Contents.(*PacketTable).Header.PrimingFrames; priming != 312 {
+		t.Errorf("expected 312 priming frames, got %d", priming)
+	}
+	reencoded := &bytes.Buffer{}
+	if err := decoded.Encode(reencoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded.Bytes(), reencoded.Bytes()) {
+		t.Error("re-encoded file differs")
+	}
+}
+
+func TestPacketTableAddEntryReset(t *testing.T) {
+	pt := &PacketTable{}
+	for _, size := range []uint64{10, 200, 3000} {
+		pt.AddEntry(size)
+	}
+	pt.Header.NumberValidFrames = 3000
+	pt.Header.PrimingFrames = 2
+	pt.Header.RemainderFrames = 70
+	buf := &bytes.Buffer{}
+	if err := pt.encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded PacketTable
+	if err := decoded.decode(bufio.NewReader(buf)); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Header != pt.Header {
+		t.Errorf("expected header %+v, got %+v", pt.Header, decoded.Header)
+	}
+	if decoded.Header.NumberPackets != 3 || len(decoded.Entry) != 3 || decoded.Entry[2] != 3000 {
+		t.Errorf("unexpected entries %v", decoded.Entry)
+	}
+
+	decoded.Reset()
+	if decoded.Header != (PacketTableHeader{}) || len(decoded.Entry) != 0 {
+		t.Errorf("expected empty packet table, got %+v", decoded)
+	}
+	decoded.AddEntry(7)
+	buf.Reset()
+	if err := decoded.encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	var reset PacketTable
+	if err := reset.decode(bufio.NewReader(buf)); err != nil {
+		t.Fatal(err)
+	}
+	if reset.Header.NumberPackets != 1 || len(reset.Entry) != 1 || reset.Entry[0] != 7 {
+		t.Errorf("unexpected packet table after reset %+v", reset)
+	}
+}
+
+func TestVLQSizeOf(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		size int
+	}{
+		{0, 1},
+		{1, 1},
+		{127, 1},
+		{128, 2},
+		{16383, 2},
+		{16384, 3},
+		{1 << 35, 6},
+		{1<<63 - 1, 9},
+	}
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		if err := encodeInt(buf, test.v); err != nil {
+			t.Fatal(err)
+		}
+		if size := VLQSizeOf(test.v); size != test.size || size != buf.Len() {
+			t.Errorf("%d: expected size %d, got %d (encoded %d)", test.v, test.size, size, buf.Len())
+		}
+		if size := VLQEncodedSize(test.v); size != test.size {
+			t.Errorf("%d: expected VLQEncodedSize %d, got %d", test.v, test.size, size)
+		}
+	}
+}
+
+func TestPacketTableEncodedSize(t *testing.T) {
+	pt := &PacketTable{}
+	for _, size := range []uint64{1, 200, 30000, 4000000} {
+		pt.AddEntry(size)
+	}
+	buf := &bytes.Buffer{}
+	if err := pt.encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if size := pt.EncodedSize(); size != buf.Len() {
+		t.Errorf("expected encoded size %d, got %d", buf.Len(), size)
+	}
+	if size := pt.EncodedEntriesSize(); size != 1+2+3+4 {
+		t.Errorf("expected entries size 10, got %d", size)
+	}
+}
+
+func TestAccumulatedByteOffset(t *testing.T) {
+	pt := &PacketTable{}
+	pt.AddEntry(10)
+	pt.AddEntry(20)
+	for index, expected := range []int64{0, 10, 30} {
+		if offset, err := pt.AccumulatedByteOffset(index); err != nil || offset != expected {
+			t.Errorf("packet %d: expected offset %d, got %d (%v)", index, expected, offset, err)
+		}
+	}
+	pt.AddEntry(30)
+	if offset, err := pt.AccumulatedByteOffset(3); err != nil || offset != 60 {
+		t.Errorf("expected offset 60 after AddEntry, got %d (%v)", offset, err)
+	}
+	if offset, err := pt.ByteOffsetForPacket(2); err != nil || offset != 30 {
+		t.Errorf("expected offset 30, got %d (%v)", offset, err)
+	}
+	entries := pt.Entries()
+	entries[0] = 100
+	if pt.Entry[0] != 10 {
+		t.Error("expected Entries to return a copy")
+	}
+	pt.Entry = entries
+	if offset, err := pt.ByteOffsetForPacket(2); err != nil || offset != 120 {
+		t.Errorf("expected offset 120 after replacing Entry, got %d (%v)", offset, err)
+	}
+	pt.Entry = append(pt.Entry, 40)
+	pt.Header.NumberPackets++
+	if offset, err := pt.AccumulatedByteOffset(4); err != nil || offset != 190 {
+		t.Errorf("expected offset 190 after appending to Entry, got %d (%v)", offset, err)
+	}
+	if _, err := pt.AccumulatedByteOffset(5); err != ErrPacketIndexOutOfRange {
+		t.Errorf("expected ErrPacketIndexOutOfRange, got %v", err)
+	}
+
+	pt.Reset()
+	pt.AddEntry(5)
+	if offset, err := pt.AccumulatedByteOffset(1); err != nil || offset != 5 {
+		t.Errorf("expected offset 5 after Reset, got %d (%v)", offset, err)
+	}
+}
+
+func TestAccumulatedByteOffsetConcurrentReaders(t *testing.T) {
+	pt := benchmarkPacketTable(1000)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if offset, err := pt.AccumulatedByteOffset(2); err != nil || offset != 201 {
+				t.Errorf("expected offset 201, got %d (%v)", offset, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkAccumulatedByteOffset(b *testing.B) {
+	pt := benchmarkPacketTable(500000)
+	if _, err := pt.AccumulatedByteOffset(0); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pt.AccumulatedByteOffset((i * 7919) % 500000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPacketTableTrim(t *testing.T) {
+	pt := &PacketTable{}
+	for _, size := range []uint64{1, 2, 3, 4} {
+		pt.AddEntry(size)
+	}
+	pt.Header.PrimingFrames = 10
+	if _, err := pt.ByteOffsetForPacket(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := pt.Trim(1, 3, 960); err != nil {
+		t.Fatal(err)
+	}
+	expected := PacketTableHeader{NumberPackets: 2, NumberValidFrames: 2 * 960}
+	if pt.Header != expected {
+		t.Errorf("expected header %+v, got %+v", expected, pt.Header)
+	}
+	if !reflect.DeepEqual(pt.Entry, []uint64{2, 3}) {
+		t.Errorf("unexpected entries %v", pt.Entry)
+	}
+	if offset, err := pt.ByteOffsetForPacket(1); err != nil || offset != 2 {
+		t.Errorf("expected offset 2, got %d (%v)", offset, err)
+	}
+	for _, r := range [][2]int64{{-1, 1}, {2, 1}, {0, 3}} {
+		if err := pt.Trim(r[0], r[1], 960); err != ErrPacketIndexOutOfRange {
+			t.Errorf("%v: expected ErrPacketIndexOutOfRange, got %v", r, err)
+		}
+	}
+}
+
+func TestPacketTableNumberPackets(t *testing.T) {
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+	pt := &PacketTable{}
+	pt.AddEntry(10)
+	if n := pt.NumberPackets(); n != 1 || len(l.messages) != 0 {
+		t.Errorf("expected 1 packet and no warnings, got %d %v", n, l.messages)
+	}
+	pt.Entry = append(pt.Entry, 20, 30)
+	if n := pt.NumberPackets(); n != 3 || len(l.messages) != 1 {
+		t.Errorf("expected 3 packets and a warning, got %d %v", n, l.messages)
+	}
+	pt.Header.NumberPackets = 5
+	if n := pt.NumberPackets(); n != 5 {
+		t.Errorf("expected 5 packets, got %d", n)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := pt.encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if pt.Header.NumberPackets != 3 {
+		t.Errorf("expected encode to correct the header to 3 packets, got %d", pt.Header.NumberPackets)
+	}
+	var decoded PacketTable
+	if err := decoded.decode(bufio.NewReader(buf)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Entry, []uint64{10, 20, 30}) || decoded.Header.NumberPackets != 3 {
+		t.Errorf("unexpected decoded table %+v", decoded)
+	}
+}
+
+func TestPacketTableIsVBR(t *testing.T) {
+	tests := []struct {
+		entries  []uint64
+		expected bool
+	}{
+		{nil, false},
+		{[]uint64{4}, false},
+		{[]uint64{4, 4, 4}, false},
+		{[]uint64{4, 5, 4}, true},
+	}
+	for _, test := range tests {
+		pt := &PacketTable{Entry: test.entries}
+		if pt.IsVBR() != test.expected {
+			t.Errorf("%v: expected IsVBR %v", test.entries, test.expected)
+		}
+	}
+}
+
+func TestPacketTableTotalFrames(t *testing.T) {
+	pt, _ := vbrTestFile().Chunks[1].AsPacketTable()
+	if total := pt.Header.TotalFrames(); total != 4*960 {
+		t.Errorf("expected %d frames, got %d", 4*960, total)
+	}
+	if pt.TotalFrames() != pt.Header.TotalFrames() {
+		t.Errorf("expected PacketTable.TotalFrames to match the header, got %d", pt.TotalFrames())
+	}
+}
@@ -0,0 +1,68 @@
+package caf
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+var ChunkTypePeak = stringToChunkType("peak")
+
+const channelPeakDataSize = 12
+
+// ChannelPeakData is the peak amplitude of one channel and the sample frame
+// at which it occurs.
+type ChannelPeakData struct {
+	Value       float32 `json:"value"`
+	FrameOffset uint64  `json:"frameOffset"`
+}
+
+// PeakChunk holds the contents of a peak chunk, with one entry per channel of
+// the audio description. EditCount matches the data chunk's edit count when
+// the peaks are up to date.
+type PeakChunk struct {
+	EditCount uint32            `json:"editCount"`
+	Peaks     []ChannelPeakData `json:"peaks"`
+}
+
+func (c *PeakChunk) decode(r io.Reader, h ChunkHeader) error {
+	if h.ChunkSize < 4 || (h.ChunkSize-4)%channelPeakDataSize != 0 {
+		return ErrInvalidChunkSize
+	}
+	if err := binary.Read(r, binary.BigEndian, &c.EditCount); err != nil {
+		return err
+	}
+	for i := int64(0); i < (h.ChunkSize-4)/channelPeakDataSize; i++ {
+		var peak ChannelPeakData
+		if err := binary.Read(r, binary.BigEndian, &peak); err != nil {
+			return err
+		}
+		c.Peaks = append(c.Peaks, peak)
+	}
+	return nil
+}
+
+func (c *PeakChunk) encode(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, &c.EditCount); err != nil {
+		return err
+	}
+	for i := range c.Peaks {
+		if err := binary.Write(w, binary.BigEndian, &c.Peaks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PeakAmplitude returns the per-channel peaks from the first peak chunk. It
+// returns ErrChunkNotFound if the file has none.
+func (cf *File) PeakAmplitude() ([]ChannelPeakData, error) {
+	peakChunk, ok := cf.ChunkByType(ChunkTypePeak)
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	cc, ok := peakChunk.AsPeak()
+	if !ok {
+		return nil, ErrChunkNotFound
+	}
+	return append([]ChannelPeakData(nil), cc.Peaks...), nil
+}
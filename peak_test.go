@@ -0,0 +1,42 @@
+package caf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPeakChunkRoundTrip(t *testing.T) {
+	f := testFile()
+	peaks := []ChannelPeakData{{Value: 0.75, FrameOffset: 1}, {Value: -0.5, FrameOffset: 1 << 40}}
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypePeak, ChunkSize: 4 + 2*12}, Contents: &PeakChunk{EditCount: 3, Peaks: peaks}})
+	decoded := roundTrip(t, f)
+	if !decoded.Equal(f) {
+		t.Errorf("expected decoded file to match:\n%s\ngot:\n%s", f.Summary(), decoded.Summary())
+	}
+	amplitudes, err := decoded.PeakAmplitude()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(amplitudes, peaks) {
+		t.Errorf("expected %v, got %v", peaks, amplitudes)
+	}
+	if _, err := testFile().PeakAmplitude(); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+}
+
+func TestPeakChunkInvalidSize(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := testFile().Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write([]byte("peak"))
+	buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 10})
+	buf.Write(make([]byte, 10))
+	err := (&File{}).Decode(buf)
+	if !errors.Is(err, ErrInvalidChunkSize) {
+		t.Errorf("expected ErrInvalidChunkSize, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package caf
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+var ChunkTypeRegion = stringToChunkType("regn")
+
+// Region flags from the CAF specification (kCAFRegionFlag_*).
+const (
+	RegionFlagLoopEnable   uint32 = 1 << 0
+	RegionFlagPlayForward  uint32 = 1 << 1
+	RegionFlagPlayBackward uint32 = 1 << 2
+)
+
+// Region is a span of the audio bounded by its markers, typically a start
+// and end marker. Like markers, regions are named through a strg chunk keyed
+// by RegionID.
+type Region struct {
+	RegionID      uint32   `json:"regionID"`
+	Flags         uint32   `json:"flags"`
+	NumberMarkers uint32   `json:"numberMarkers"`
+	Markers       []Marker `json:"markers"`
+}
+
+// RegionChunk holds the contents of a regn chunk.
+type RegionChunk struct {
+	SMPTETimeType uint32   `json:"smpteTimeType"`
+	NumberRegions uint32   `json:"numberRegions"`
+	Regions       []Region `json:"regions"`
+}
+
+func (c *Region) decode(r io.Reader) error {
+	if err := binary.Read(r, binary.BigEndian, &c.RegionID); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.BigEndian, &c.Flags); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.BigEndian, &c.NumberMarkers); err != nil {
+		return err
+	}
+	for i := uint32(0); i < c.NumberMarkers; i++ {
+		var marker Marker
+		if err := binary.Read(r, binary.BigEndian, &marker); err != nil {
+			return err
+		}
+		c.Markers = append(c.Markers, marker)
+	}
+	return nil
+}
+
+func (c *Region) encode(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, &c.RegionID); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, &c.Flags); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, &c.NumberMarkers); err != nil {
+		return err
+	}
+	for i := uint32(0); i < c.NumberMarkers; i++ {
+		if err := binary.Write(w, binary.BigEndian, &c.Markers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RegionChunk) decode(r io.Reader) error {
+	if err := binary.Read(r, binary.BigEndian, &c.SMPTETimeType); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.BigEndian, &c.NumberRegions); err != nil {
+		return err
+	}
+	for i := uint32(0); i < c.NumberRegions; i++ {
+		var region Region
+		if err := region.decode(r); err != nil {
+			return err
+		}
+		c.Regions = append(c.Regions, region)
+	}
+	return nil
+}
+
+func (c *RegionChunk) encode(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, &c.SMPTETimeType); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, &c.NumberRegions); err != nil {
+		return err
+	}
+	for i := uint32(0); i < c.NumberRegions; i++ {
+		if err := c.Regions[i].encode(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
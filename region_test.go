@@ -0,0 +1,29 @@
+package caf
+
+import (
+	"testing"
+)
+
+func TestRegionChunkRoundTrip(t *testing.T) {
+	f := testFile()
+	regions := &RegionChunk{
+		SMPTETimeType: 30,
+		NumberRegions: 2,
+		Regions: []Region{
+			{RegionID: 1, Flags: RegionFlagLoopEnable | RegionFlagPlayForward, NumberMarkers: 2, Markers: []Marker{
+				{Type: stringToUint32("rbeg"), FramePosition: 0, ID: 1},
+				{Type: stringToUint32("rend"), FramePosition: 44100, ID: 2},
+			}},
+			{RegionID: 2},
+		},
+	}
+	f.Chunks = append(f.Chunks, Chunk{Header: ChunkHeader{ChunkType: ChunkTypeRegion, ChunkSize: 8 + 12 + 2*28 + 12}, Contents: regions})
+	decoded := roundTrip(t, f)
+	if !decoded.Equal(f) {
+		t.Errorf("expected decoded file to match:\n%s\ngot:\n%s", f.Summary(), decoded.Summary())
+	}
+	c := &decoded.Chunks[len(decoded.Chunks)-1]
+	if cc, ok := c.AsRegions(); !ok || cc.Regions[0].Markers[1].FramePosition != 44100 {
+		t.Errorf("unexpected regions %+v", cc)
+	}
+}
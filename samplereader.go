@@ -0,0 +1,85 @@
+package caf
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// SampleReader reads the linear PCM audio of a file as interleaved float64
+// samples. Integer samples are scaled to the range [-1, 1).
+type SampleReader struct {
+	format AudioFormat
+	order  binary.ByteOrder
+	data   []byte
+	offset int
+}
+
+// NewSampleReader returns a reader over the first data chunk of f. The
+// format must be 16, 24 or 32-bit integer or 32 or 64-bit float linear PCM.
+// Integer samples are signed, as they always are in CAF files, and the byte
+// order comes from CAFLinearPCMFormatFlagIsLittleEndian.
+func NewSampleReader(f *File) (*SampleReader, error) {
+	af, err := f.audioFormat()
+	if err != nil {
+		return nil, err
+	}
+	if !af.IsPCM() {
+		return nil, ErrUnsupportedFormat
+	}
+	if af.IsFloat() {
+		if af.BitsPerChannel != 32 && af.BitsPerChannel != 64 {
+			return nil, ErrUnsupportedFormat
+		}
+	} else if af.BitsPerChannel != 16 && af.BitsPerChannel != 24 && af.BitsPerChannel != 32 {
+		return nil, ErrUnsupportedFormat
+	}
+	data, err := f.FirstAudioData()
+	if err != nil {
+		return nil, err
+	}
+	sr := &SampleReader{format: *af, order: binary.BigEndian, data: data.Data}
+	if af.ByteOrderIsLittleEndian() {
+		sr.order = binary.LittleEndian
+	}
+	return sr, nil
+}
+
+// Read fills samples with the next samples of audio, returning how many it
+// read. It returns io.EOF once no whole samples remain.
+func (sr *SampleReader) Read(samples []float64) (int, error) {
+	size := int(sr.format.BitsPerChannel / 8)
+	n := (len(sr.data) - sr.offset) / size
+	if n == 0 && len(samples) > 0 {
+		return 0, io.EOF
+	}
+	if n > len(samples) {
+		n = len(samples)
+	}
+	for i := 0; i < n; i++ {
+		samples[i] = sr.sample(sr.data[sr.offset : sr.offset+size])
+		sr.offset += size
+	}
+	return n, nil
+}
+
+func (sr *SampleReader) sample(b []byte) float64 {
+	switch {
+	case sr.format.IsFloat() && len(b) == 4:
+		return float64(math.Float32frombits(sr.order.Uint32(b)))
+	case sr.format.IsFloat():
+		return math.Float64frombits(sr.order.Uint64(b))
+	case len(b) == 2:
+		return float64(int16(sr.order.Uint16(b))) / (1 << 15)
+	case len(b) == 3:
+		var v int32
+		if sr.order == binary.BigEndian {
+			v = int32(b[0])<<24 | int32(b[1])<<16 | int32(b[2])<<8
+		} else {
+			v = int32(b[2])<<24 | int32(b[1])<<16 | int32(b[0])<<8
+		}
+		return float64(v>>8) / (1 << 23)
+	default:
+		return float64(int32(sr.order.Uint32(b))) / (1 << 31)
+	}
+}
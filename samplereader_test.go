@@ -0,0 +1,69 @@
+package caf
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func pcmTestFile(bits uint32, flags uint32, data []byte) *File {
+	f := NewFile(AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM, FormatFlags: flags, BytesPerPacket: bits / 8 * 2, FramesPerPacket: 1, ChannelsPerPacket: 2, BitsPerChannel: bits})
+	f.Chunks = append(f.Chunks, NewAudioDataChunk(data, 0))
+	return f
+}
+
+// The flags in these tests are the ones Apple's tools write: 2 for little
+// endian integer, 0 for big endian integer and 3 for little endian float.
+func TestSampleReader(t *testing.T) {
+	float32Bytes := make([]byte, 8)
+	binary.LittleEndian.PutUint32(float32Bytes, math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(float32Bytes[4:], math.Float32bits(-0.25))
+	tests := []struct {
+		name     string
+		f        *File
+		expected []float64
+	}{
+		{"16-bit little endian", pcmTestFile(16, 2, []byte{0x00, 0x40, 0x00, 0x80, 0xff}), []float64{0.5, -1}},
+		{"16-bit big endian", pcmTestFile(16, 0, []byte{0x40, 0x00, 0xc0, 0x00}), []float64{0.5, -0.5}},
+		{"24-bit little endian", pcmTestFile(24, 2, []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xc0}), []float64{0.5, -0.5}},
+		{"24-bit big endian", pcmTestFile(24, 0, []byte{0x40, 0x00, 0x00, 0x80, 0x00, 0x00}), []float64{0.5, -1}},
+		{"32-bit big endian", pcmTestFile(32, 0, []byte{0x40, 0, 0, 0}), []float64{0.5}},
+		{"32-bit float little endian", pcmTestFile(32, 3, float32Bytes), []float64{0.5, -0.25}},
+	}
+	for _, test := range tests {
+		sr, err := NewSampleReader(test.f)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var samples []float64
+		buf := make([]float64, 1)
+		for {
+			n, err := sr.Read(buf)
+			samples = append(samples, buf[:n]...)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+		if !reflect.DeepEqual(samples, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, samples)
+		}
+	}
+}
+
+func TestNewSampleReaderErrors(t *testing.T) {
+	if _, err := NewSampleReader(vbrTestFile()); err != ErrUnsupportedFormat {
+		t.Errorf("expected ErrUnsupportedFormat for opus, got %v", err)
+	}
+	if _, err := NewSampleReader(pcmTestFile(8, 0, nil)); err != ErrUnsupportedFormat {
+		t.Errorf("expected ErrUnsupportedFormat for 8-bit audio, got %v", err)
+	}
+	noData := pcmTestFile(16, 0, nil)
+	noData.Chunks = noData.Chunks[:1]
+	if _, err := NewSampleReader(noData); err != ErrChunkNotFound {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+}
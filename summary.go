@@ -0,0 +1,60 @@
+package caf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String describes the format, e.g. "lpcm 44100 Hz 16-bit 2ch".
+func (c *AudioFormat) String() string {
+	if c.BitsPerChannel == 0 {
+		return fmt.Sprintf("%v %g Hz %dch", c.FormatID, c.SampleRate, c.ChannelsPerPacket)
+	}
+	return fmt.Sprintf("%v %g Hz %d-bit %dch", c.FormatID, c.SampleRate, c.BitsPerChannel, c.ChannelsPerPacket)
+}
+
+// Summary returns a multi-line description of the file's header and chunks
+// for use in logs and test failures.
+func (cf *File) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v version %d flags %d\n", cf.FileHeader.FileType, cf.FileHeader.FileVersion, cf.FileHeader.FileFlags)
+	for i := range cf.Chunks {
+		c := &cf.Chunks[i]
+		if c.Header.ChunkSize == -1 {
+			fmt.Fprintf(&sb, "%s (streaming)", c.TypeString())
+		} else {
+			fmt.Fprintf(&sb, "%s (%d bytes)", c.TypeString(), c.Header.ChunkSize)
+		}
+		if description := c.contentSummary(); description != "" {
+			fmt.Fprintf(&sb, ": %s", description)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (c *Chunk) contentSummary() string {
+	switch cc := c.Contents.(type) {
+	case *AudioFormat:
+		return cc.String()
+	case *ChannelLayout:
+		return fmt.Sprintf("tag %s, bitmap 0x%08X, %d descriptions", cc.ChannelLayoutTagString(), cc.ChannelBitmap, cc.NumberChannelDescriptions)
+	case *CAFStringsChunk:
+		return fmt.Sprintf("%d entries [%s]", len(cc.Strings), strings.Join(cc.Keys(), ", "))
+	case *Data:
+		return fmt.Sprintf("%d bytes of audio, edit count %d", len(cc.Data), cc.EditCount)
+	case *PacketTable:
+		return fmt.Sprintf("%d packets %d valid frames %d priming %d remainder",
+			cc.Header.NumberPackets, cc.Header.NumberValidFrames, cc.Header.PrimingFrames, cc.Header.RemainderFrames)
+	case *FreeSpaceChunk:
+		return fmt.Sprintf("%d bytes of padding", cc.Size)
+	case *MarkerChunk:
+		return fmt.Sprintf("%d markers", cc.NumberMarkers)
+	case *RegionChunk:
+		return fmt.Sprintf("%d regions", cc.NumberRegions)
+	case *PeakChunk:
+		return fmt.Sprintf("%d channel peaks, edit count %d", len(cc.Peaks), cc.EditCount)
+	default:
+		return ""
+	}
+}
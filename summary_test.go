@@ -0,0 +1,42 @@
+package caf
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSummaryHelenKane(t *testing.T) {
+	f, err := Open("samples/helenkane.caf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `caff version 1 flags 0
+Audio Description (32 bytes): opus 48000 Hz 2ch
+Channel Layout (12 bytes): tag Stereo, bitmap 0x00000000, 0 descriptions
+Information (26 bytes): 1 entries [encoder]
+Audio Data (2750070 bytes): 2750066 bytes of audio, edit count 0
+Packet Table (18263 bytes): 9249 packets 8879040 valid frames 0 priming 0 remainder
+`
+	if summary := f.Summary(); summary != expected {
+		t.Errorf("expected summary:\n%s\ngot:\n%s", expected, summary)
+	}
+}
+
+func TestAudioFormatString(t *testing.T) {
+	af := testFile().Chunks[0].Contents.(*AudioFormat)
+	if s := af.String(); s != "lpcm 44100 Hz 16-bit 2ch" {
+		t.Errorf("unexpected format string %q", s)
+	}
+}
+
+func ExampleFile_Summary() {
+	f := testFile()
+	f.Chunks[3].Header.ChunkSize = -1
+	os.Stdout.WriteString(f.Summary())
+	// Output:
+	// caff version 1 flags 0
+	// Audio Description (32 bytes): lpcm 44100 Hz 16-bit 2ch
+	// MIDI (2 bytes)
+	// MIDI (1 bytes)
+	// Audio Data (streaming): 8 bytes of audio, edit count 0
+}
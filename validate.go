@@ -0,0 +1,93 @@
+package caf
+
+import "fmt"
+
+// Validate checks the structural integrity of the file, returning the first
+// violation found. Chunk sizes must match the contents unless they are 0.
+func (cf *File) Validate() error {
+	if err := cf.FileHeader.Validate(); err != nil {
+		return err
+	}
+	descChunks := cf.ChunksOfType(ChunkTypeAudioDescription)
+	if len(descChunks) == 0 {
+		return ErrMissingAudioDescription
+	}
+	if len(descChunks) > 1 {
+		return ErrMultipleAudioDescriptions
+	}
+	if len(cf.ChunksOfType(ChunkTypeAudioData)) > 1 {
+		return ErrMultipleAudioData
+	}
+	if af, ok := descChunks[0].AsAudioFormat(); ok {
+		if err := af.Validate(); err != nil {
+			return err
+		}
+		paktChunk, hasPakt := cf.ChunkByType(ChunkTypePacketTable)
+		if af.RequiresPacketTable() && !hasPakt {
+			return ErrMissingPacketTable
+		}
+		// For constant bit rate audio the packet count follows from the
+		// size of the audio data.
+		if hasPakt && !af.RequiresPacketTable() {
+			pt, ptOK := paktChunk.AsPacketTable()
+			data, err := cf.FirstAudioData()
+			if ptOK && err == nil && pt.Header.NumberPackets != data.Size()/int64(af.BytesPerPacket) {
+				return ErrPacketCountMismatch
+			}
+		}
+	}
+	for i, c := range cf.Chunks {
+		// A size of 0 is left for Encode to compute.
+		if c.Header.ChunkSize != 0 && !c.IsValidEncodedSize() {
+			return fmt.Errorf("%w: %v chunk %d has size %d", ErrInvalidChunkSize, c.Header.ChunkType, i, c.Header.ChunkSize)
+		}
+		switch cc := c.Contents.(type) {
+		case *PacketTable:
+			if int64(len(cc.Entry)) != cc.Header.NumberPackets {
+				return ErrPacketCountMismatch
+			}
+		case *ChannelLayout:
+			if err := cc.Validate(); err != nil {
+				return err
+			}
+		case *CAFStringsChunk:
+			if err := cc.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MissingRequiredChunks returns the types of the chunks a playable file needs
+// but this one lacks: desc and data, and pakt when the format requires a
+// packet table.
+func (cf *File) MissingRequiredChunks() []FourByteString {
+	var missing []FourByteString
+	descChunk, hasDesc := cf.ChunkByType(ChunkTypeAudioDescription)
+	if !hasDesc {
+		missing = append(missing, ChunkTypeAudioDescription)
+	}
+	if _, ok := cf.ChunkByType(ChunkTypeAudioData); !ok {
+		missing = append(missing, ChunkTypeAudioData)
+	}
+	if hasDesc {
+		af, ok := descChunk.AsAudioFormat()
+		if _, hasPakt := cf.ChunkByType(ChunkTypePacketTable); ok && af.RequiresPacketTable() && !hasPakt {
+			missing = append(missing, ChunkTypePacketTable)
+		}
+	}
+	return missing
+}
+
+// HasRequiredChunks reports whether the file has every chunk a playable file
+// needs.
+func (cf *File) HasRequiredChunks() bool {
+	return len(cf.MissingRequiredChunks()) == 0
+}
+
+// IsPlayable reports whether the file has its required chunks and passes
+// Validate, which also checks the audio format and channel layout.
+func (cf *File) IsPlayable() bool {
+	return cf.HasRequiredChunks() && cf.Validate() == nil
+}
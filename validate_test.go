@@ -0,0 +1,160 @@
+package caf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	vbrFormat := &AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2}
+	tests := []struct {
+		name   string
+		modify func(f *File)
+		err    error
+	}{
+		{"valid", func(f *File) {}, nil},
+		{"invalid file type", func(f *File) {
+			f.FileHeader.FileType = stringToChunkType("riff")
+		}, ErrInvalidFileType},
+		{"unsupported version", func(f *File) {
+			f.FileHeader.FileVersion = 2
+		}, ErrUnsupportedFileVersion},
+		{"missing desc", func(f *File) {
+			f.Chunks = f.Chunks[1:]
+		}, ErrMissingAudioDescription},
+		{"multiple desc", func(f *File) {
+			f.Chunks = append(f.Chunks, f.Chunks[0])
+		}, ErrMultipleAudioDescriptions},
+		{"wrong chunk size", func(f *File) {
+			f.Chunks[1].Header.ChunkSize = 3
+		}, ErrInvalidChunkSize},
+		{"unset chunk size", func(f *File) {
+			f.Chunks[1].Header.ChunkSize = 0
+		}, nil},
+		{"streaming data chunk", func(f *File) {
+			f.Chunks[3].Header.ChunkSize = -1
+		}, nil},
+		{"multiple data", func(f *File) {
+			f.Chunks = append(f.Chunks, f.Chunks[3])
+		}, ErrMultipleAudioData},
+		{"vbr without packet table", func(f *File) {
+			f.Chunks[0].Contents = vbrFormat
+		}, ErrMissingPacketTable},
+		{"vbr with packet table", func(f *File) {
+			f.Chunks[0].Contents = vbrFormat
+			f.Chunks = append(f.Chunks, Chunk{
+				Header:   ChunkHeader{ChunkType: ChunkTypePacketTable},
+				Contents: &PacketTable{Header: PacketTableHeader{NumberPackets: 2}, Entry: []uint64{4, 4}},
+			})
+		}, nil},
+		{"packet count mismatch", func(f *File) {
+			f.Chunks = append(f.Chunks, Chunk{
+				Header:   ChunkHeader{ChunkType: ChunkTypePacketTable},
+				Contents: &PacketTable{Header: PacketTableHeader{NumberPackets: 3}, Entry: []uint64{4, 4}},
+			})
+		}, ErrPacketCountMismatch},
+		{"cbr packet count does not match data", func(f *File) {
+			f.Chunks = append(f.Chunks, Chunk{
+				Header:   ChunkHeader{ChunkType: ChunkTypePacketTable},
+				Contents: &PacketTable{Header: PacketTableHeader{NumberPackets: 3}, Entry: []uint64{4, 4, 4}},
+			})
+		}, ErrPacketCountMismatch},
+		{"cbr with packet table", func(f *File) {
+			f.Chunks = append(f.Chunks, Chunk{
+				Header:   ChunkHeader{ChunkType: ChunkTypePacketTable},
+				Contents: &PacketTable{Header: PacketTableHeader{NumberPackets: 2}, Entry: []uint64{4, 4}},
+			})
+		}, nil},
+		{"channel description count mismatch", func(f *File) {
+			f.Chunks = append(f.Chunks, Chunk{
+				Header:   ChunkHeader{ChunkType: ChunkTypeChannelLayout},
+				Contents: &ChannelLayout{NumberChannelDescriptions: 2, Channels: []ChannelDescription{{}}},
+			})
+		}, ErrChannelDescriptionCountMismatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := testFile()
+			test.modify(f)
+			if err := f.Validate(); !errors.Is(err, test.err) {
+				t.Errorf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateAudioFormat(t *testing.T) {
+	f := testFile()
+	f.Chunks[0].Contents.(*AudioFormat).SampleRate = 0
+	if err := f.Validate(); err != ErrInvalidSampleRate {
+		t.Errorf("expected ErrInvalidSampleRate, got %v", err)
+	}
+}
+
+func TestMissingRequiredChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     *File
+		missing  []FourByteString
+		playable bool
+	}{
+		{"complete", testFile(), nil, true},
+		{"vbr with packet table", vbrTestFile(), nil, true},
+		{"no audio data", NewFile(AudioFormat{SampleRate: 44100, FormatID: FormatLinearPCM, ChannelsPerPacket: 1, BitsPerChannel: 16, BytesPerPacket: 2, FramesPerPacket: 1}), []FourByteString{ChunkTypeAudioData}, false},
+		{"empty", &File{FileHeader: NewFileHeader()}, []FourByteString{ChunkTypeAudioDescription, ChunkTypeAudioData}, false},
+		{"vbr without packet table", func() *File {
+			f := vbrTestFile()
+			f.RemoveChunk(ChunkTypePacketTable)
+			return f
+		}(), []FourByteString{ChunkTypePacketTable}, false},
+		{"invalid format", func() *File {
+			f := testFile()
+			f.Chunks[0].Contents.(*AudioFormat).SampleRate = 0
+			return f
+		}(), nil, false},
+	}
+	for _, test := range tests {
+		if missing := test.file.MissingRequiredChunks(); !reflect.DeepEqual(missing, test.missing) {
+			t.Errorf("%s: expected missing %v, got %v", test.name, test.missing, missing)
+		}
+		if test.file.HasRequiredChunks() != (len(test.missing) == 0) {
+			t.Errorf("%s: HasRequiredChunks disagrees with missing %v", test.name, test.missing)
+		}
+		if test.file.IsPlayable() != test.playable {
+			t.Errorf("%s: expected IsPlayable %v", test.name, test.playable)
+		}
+	}
+}
+
+func TestValidateEditedFile(t *testing.T) {
+	f, err := Open("samples/helenkane.caf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AddInformation("title", "x")
+	if err := f.Validate(); err != nil {
+		t.Errorf("expected edited file to validate, got %v", err)
+	}
+	if !f.IsPlayable() {
+		t.Error("expected edited file to be playable")
+	}
+
+	af, _ := NewStereoAudioFormat(44100, 16)
+	built := NewFile(af)
+	data := NewAudioDataChunk(nil, 0)
+	data.Contents.(*Data).Append(make([]byte, 8))
+	built.Chunks = append(built.Chunks, data)
+	if err := built.Validate(); !errors.Is(err, ErrInvalidChunkSize) {
+		t.Errorf("expected ErrInvalidChunkSize before UpdateSize, got %v", err)
+	}
+	if err := built.Chunks[1].UpdateSize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := built.Validate(); err != nil {
+		t.Errorf("expected built file to validate, got %v", err)
+	}
+	if !built.IsPlayable() {
+		t.Error("expected built file to be playable")
+	}
+}
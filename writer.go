@@ -0,0 +1,136 @@
+package caf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// AudioWriter encodes a CAF file incrementally as audio arrives.
+//
+// When the underlying writer can seek, audio is written straight through and
+// the data chunk size is patched on Close. Otherwise constant bit rate audio
+// is written as a streaming data chunk of unknown size, and variable bit rate
+// audio is buffered until Close so the packet table can precede it.
+type AudioWriter struct {
+	w               io.Writer
+	seeker          io.Seeker
+	format          AudioFormat
+	packetTable     PacketTable
+	buffer          bytes.Buffer
+	dataChunkOffset int64
+	dataSize        int64
+	closed          bool
+}
+
+// NewAudioWriter writes the file header and audio description to w and
+// returns a writer for the audio that follows.
+func NewAudioWriter(w io.Writer, af AudioFormat) (*AudioWriter, error) {
+	aw := &AudioWriter{w: w, format: af}
+	if seeker, ok := w.(io.Seeker); ok {
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			aw.seeker = seeker
+			aw.dataChunkOffset = offset
+		}
+	}
+	header := NewFileHeader()
+	if err := header.Encode(w); err != nil {
+		return nil, err
+	}
+	desc := NewAudioDescriptionChunk(aw.format)
+	if err := desc.Encode(w); err != nil {
+		return nil, err
+	}
+	aw.dataChunkOffset += fileHeaderSize + chunkHeaderSize + desc.Header.ChunkSize
+	if aw.buffersData() {
+		return aw, nil
+	}
+	dataSize := int64(-1)
+	if aw.seeker != nil {
+		dataSize = 4
+	}
+	if err := aw.writeDataHeader(dataSize); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+func (aw *AudioWriter) isVBR() bool {
+	return aw.format.BytesPerPacket == 0
+}
+
+func (aw *AudioWriter) buffersData() bool {
+	return aw.seeker == nil && aw.isVBR()
+}
+
+func (aw *AudioWriter) writeDataHeader(size int64) error {
+	header := ChunkHeader{ChunkType: ChunkTypeAudioData, ChunkSize: size}
+	if err := binary.Write(aw.w, binary.BigEndian, &header); err != nil {
+		return err
+	}
+	var editCount uint32
+	return binary.Write(aw.w, binary.BigEndian, &editCount)
+}
+
+// WriteFrames appends encoded audio. For variable bit rate formats each call
+// must hold exactly one packet.
+func (aw *AudioWriter) WriteFrames(b []byte) (int, error) {
+	if aw.closed {
+		return 0, ErrWriterClosed
+	}
+	var n int
+	var err error
+	if aw.buffersData() {
+		n, err = aw.buffer.Write(b)
+	} else {
+		n, err = aw.w.Write(b)
+	}
+	aw.dataSize += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if aw.isVBR() {
+		aw.packetTable.AddEntry(uint64(n))
+		aw.packetTable.Header.NumberValidFrames += int64(aw.format.FramesPerPacket)
+	}
+	return n, nil
+}
+
+// Close finishes the file. It does not close the underlying writer.
+func (aw *AudioWriter) Close() error {
+	if aw.closed {
+		return ErrWriterClosed
+	}
+	aw.closed = true
+	var pakt *Chunk
+	if aw.isVBR() {
+		paktChunk := NewPacketTableChunk(aw.packetTable)
+		pakt = &paktChunk
+	}
+	if aw.buffersData() {
+		if err := pakt.Encode(aw.w); err != nil {
+			return err
+		}
+		if err := aw.writeDataHeader(4 + aw.dataSize); err != nil {
+			return err
+		}
+		_, err := aw.buffer.WriteTo(aw.w)
+		return err
+	}
+	if aw.seeker == nil {
+		return nil
+	}
+	if pakt != nil {
+		if err := pakt.Encode(aw.w); err != nil {
+			return err
+		}
+	}
+	if _, err := aw.seeker.Seek(aw.dataChunkOffset+4, io.SeekStart); err != nil {
+		return err
+	}
+	if err := binary.Write(aw.w, binary.BigEndian, 4+aw.dataSize); err != nil {
+		return err
+	}
+	_, err := aw.seeker.Seek(0, io.SeekEnd)
+	return err
+}
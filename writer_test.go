@@ -0,0 +1,113 @@
+package caf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	writerPCMFormat  = AudioFormat{SampleRate: 8000, FormatID: FormatLinearPCM, BytesPerPacket: 2, FramesPerPacket: 1, ChannelsPerPacket: 1, BitsPerChannel: 16}
+	writerOpusFormat = AudioFormat{SampleRate: 48000, FormatID: stringToChunkType("opus"), FramesPerPacket: 960, ChannelsPerPacket: 2}
+)
+
+func writeAudio(t *testing.T, w io.Writer, af AudioFormat, packets [][]byte) {
+	aw, err := NewAudioWriter(w, af)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range packets {
+		if n, err := aw.WriteFrames(p); err != nil {
+			t.Fatal(err)
+		} else if n != len(p) {
+			t.Fatalf("expected to write %d bytes, wrote %d", len(p), n)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aw.WriteFrames([]byte{1}); err != ErrWriterClosed {
+		t.Errorf("expected ErrWriterClosed, got %v", err)
+	}
+}
+
+func checkWrittenAudio(t *testing.T, f *File, af AudioFormat, packets [][]byte, dataSize int64) {
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if format, _ := f.Chunks[0].AsAudioFormat(); *format != af {
+		t.Errorf("expected format %+v, got %+v", af, *format)
+	}
+	dataChunk, ok := f.ChunkByType(ChunkTypeAudioData)
+	if !ok {
+		t.Fatal("expected data chunk")
+	}
+	if dataChunk.Header.ChunkSize != dataSize {
+		t.Errorf("expected data chunk size %d, got %d", dataSize, dataChunk.Header.ChunkSize)
+	}
+	data, _ := dataChunk.AsData()
+	if expected := bytes.Join(packets, nil); !bytes.Equal(data.Data, expected) {
+		t.Errorf("expected audio %v, got %v", expected, data.Data)
+	}
+	paktChunk, ok := f.ChunkByType(ChunkTypePacketTable)
+	if af.BytesPerPacket != 0 {
+		if ok {
+			t.Error("expected no packet table for constant bit rate audio")
+		}
+		return
+	}
+	if !ok {
+		t.Fatal("expected packet table")
+	}
+	pt, _ := paktChunk.AsPacketTable()
+	if pt.Header.NumberPackets != int64(len(packets)) || pt.Header.NumberValidFrames != int64(len(packets))*960 {
+		t.Errorf("unexpected packet table header %+v", pt.Header)
+	}
+	for i, p := range packets {
+		if pt.Entry[i] != uint64(len(p)) {
+			t.Errorf("packet %d: expected size %d, got %d", i, len(p), pt.Entry[i])
+		}
+	}
+}
+
+func TestAudioWriterSeekable(t *testing.T) {
+	packets := [][]byte{{1, 2, 3}, {4, 5}, {6, 7, 8, 9}}
+	for _, af := range []AudioFormat{writerPCMFormat, writerOpusFormat} {
+		path := filepath.Join(t.TempDir(), "test.caf")
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeAudio(t, file, af, packets)
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkWrittenAudio(t, f, af, packets, 4+9)
+	}
+}
+
+func TestAudioWriterNotSeekable(t *testing.T) {
+	packets := [][]byte{{1, 2, 3}, {4, 5}, {6, 7, 8, 9}}
+	tests := []struct {
+		format   AudioFormat
+		dataSize int64
+	}{
+		{writerPCMFormat, -1},
+		{writerOpusFormat, 4 + 9},
+	}
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		writeAudio(t, buf, test.format, packets)
+		f, err := Decode(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkWrittenAudio(t, f, test.format, packets, test.dataSize)
+	}
+}